@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// aggregateTailLines is how much history each container contributes when the
+// aggregated view starts streaming it.
+const aggregateTailLines = 100
+
+// logLine is a single line of an aggregated log, tagged with where it came from.
+type logLine struct {
+	timestamp time.Time
+	pod       string
+	container string
+	text      string
+}
+
+func (l logLine) Title() string       { return l.text }
+func (l logLine) FilterValue() string { return l.pod + "/" + l.container + " " + l.text }
+
+func (l logLine) tag() string {
+	return fmt.Sprintf("[%s/%s]", l.pod, l.container)
+}
+
+var tagColors = []lipgloss.Color{"39", "42", "170", "214", "81", "204", "141", "221", "75", "118"}
+
+// tagStyle picks a stable color for every pod/container pair.
+func tagStyle(tag string) lipgloss.Style {
+	h := fnv.New32a()
+	h.Write([]byte(tag))
+	return lipgloss.NewStyle().Foreground(tagColors[h.Sum32()%uint32(len(tagColors))])
+}
+
+type logLinesMsg []logLine
+
+// logAggregator follows the logs of every container of every pod matching a
+// label selector, including pods created after it started.
+type logAggregator struct {
+	clientset *kubernetes.Clientset
+	namespace string
+	selector  string
+	lines     chan logLine
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	mu        sync.Mutex
+	streaming map[string]bool
+	lastSeen  map[string]time.Time
+}
+
+func newLogAggregator(clientset *kubernetes.Clientset, namespace string, selector string) *logAggregator {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &logAggregator{
+		clientset: clientset,
+		namespace: namespace,
+		selector:  selector,
+		lines:     make(chan logLine, 1024),
+		ctx:       ctx,
+		cancel:    cancel,
+		streaming: map[string]bool{},
+		lastSeen:  map[string]time.Time{},
+	}
+}
+
+// start watches for matching pods until the aggregator is stopped.
+func (a *logAggregator) start() {
+	go func() {
+		for a.ctx.Err() == nil {
+			err := a.watchPods()
+			if err != nil {
+				a.send(logLine{timestamp: time.Now(), pod: "kuco", container: "watch", text: err.Error()})
+				select {
+				case <-a.ctx.Done():
+				case <-time.After(5 * time.Second):
+				}
+			}
+		}
+	}()
+}
+
+func (a *logAggregator) stop() {
+	a.cancel()
+}
+
+// watchPods streams every running container it is told about. It returns when
+// the watch expires so that start can re-establish it.
+func (a *logAggregator) watchPods() error {
+	w, err := a.clientset.CoreV1().Pods(a.namespace).Watch(a.ctx, metav1.ListOptions{LabelSelector: a.selector})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	for event := range w.ResultChan() {
+		if event.Type != watch.Added && event.Type != watch.Modified {
+			continue
+		}
+
+		pod, ok := event.Object.(*corev1.Pod)
+		if !ok {
+			continue
+		}
+
+		var statuses []corev1.ContainerStatus
+		statuses = append(statuses, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			if status.State.Running != nil {
+				a.follow(pod.Name, status.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// follow starts streaming a container unless it is already being streamed. A
+// container that restarts is resumed from the last line seen.
+func (a *logAggregator) follow(pod string, container string) {
+	key := pod + "/" + container
+
+	a.mu.Lock()
+	if a.streaming[key] {
+		a.mu.Unlock()
+		return
+	}
+	a.streaming[key] = true
+	since, resumed := a.lastSeen[key]
+	a.mu.Unlock()
+
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			delete(a.streaming, key)
+			a.mu.Unlock()
+		}()
+
+		opts := &corev1.PodLogOptions{Container: container, Follow: true, Timestamps: true}
+		if resumed {
+			opts.SinceTime = &metav1.Time{Time: since.Add(time.Nanosecond)}
+		} else {
+			tail := int64(aggregateTailLines)
+			opts.TailLines = &tail
+		}
+
+		stream, err := a.clientset.CoreV1().Pods(a.namespace).GetLogs(pod, opts).Stream(a.ctx)
+		if err != nil {
+			a.send(logLine{timestamp: time.Now(), pod: pod, container: container, text: err.Error()})
+			return
+		}
+		defer stream.Close()
+
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := parseTimestampedLine(pod, container, scanner.Text())
+
+			a.mu.Lock()
+			a.lastSeen[key] = line.timestamp
+			a.mu.Unlock()
+
+			if !a.send(line) {
+				return
+			}
+		}
+	}()
+}
+
+func (a *logAggregator) send(line logLine) bool {
+	select {
+	case a.lines <- line:
+		return true
+	case <-a.ctx.Done():
+		return false
+	}
+}
+
+// wait returns a command delivering the next batch of lines.
+func (a *logAggregator) wait() tea.Cmd {
+	return func() tea.Msg {
+		var batch logLinesMsg
+		select {
+		case line := <-a.lines:
+			batch = append(batch, line)
+		case <-a.ctx.Done():
+			return nil
+		}
+
+		// Drain whatever else is already queued so the list is updated in batches
+		for len(batch) < cap(a.lines) {
+			select {
+			case line := <-a.lines:
+				batch = append(batch, line)
+			default:
+				return batch
+			}
+		}
+
+		return batch
+	}
+}
+
+// parseTimestampedLine tags a line and takes its timestamp from the prefix the
+// API server adds, falling back to the time it was received.
+func parseTimestampedLine(pod string, container string, raw string) logLine {
+	ts, text, ok := splitTimestamp(raw)
+	if !ok {
+		ts = time.Now()
+	}
+
+	return logLine{timestamp: ts, pod: pod, container: container, text: text}
+}
+
+// mergeLogLines merges a batch into lines, which are ordered by timestamp, in
+// a single pass. The oldest lines are dropped once maxLines or maxBytes is
+// exceeded and the number dropped is returned.
+func mergeLogLines(lines []list.Item, batch []logLine, maxLines int, maxBytes int) ([]list.Item, int) {
+	sort.SliceStable(batch, func(a, b int) bool {
+		return batch[a].timestamp.Before(batch[b].timestamp)
+	})
+
+	merged := make([]list.Item, 0, len(lines)+len(batch))
+	n := 0
+	for _, line := range batch {
+		for n < len(lines) {
+			other, ok := lines[n].(logLine)
+			if ok && other.timestamp.After(line.timestamp) {
+				break
+			}
+			merged = append(merged, lines[n])
+			n++
+		}
+		merged = append(merged, line)
+	}
+	merged = append(merged, lines[n:]...)
+
+	size := 0
+	for _, line := range merged {
+		size += len(itemText(line))
+	}
+
+	dropped := 0
+	for dropped < len(merged)-1 && (len(merged)-dropped > maxLines || size > maxBytes) {
+		size -= len(itemText(merged[dropped]))
+		dropped++
+	}
+
+	return merged[dropped:], dropped
+}
+
+// workloadItemList lists the Deployments, StatefulSets, DaemonSets and Jobs
+// of a namespace as the references ResolveLogSelector accepts, showing errors
+// as an entry.
+func workloadItemList(clientset *kubernetes.Clientset, namespace string) []list.Item {
+	itemList := []list.Item{}
+	fail := func(err error) []list.Item {
+		return append(itemList, item(err.Error()))
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fail(err)
+	}
+	for _, d := range deployments.Items {
+		itemList = append(itemList, item("deploy/"+d.Name))
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fail(err)
+	}
+	for _, sts := range statefulSets.Items {
+		itemList = append(itemList, item("sts/"+sts.Name))
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fail(err)
+	}
+	for _, ds := range daemonSets.Items {
+		itemList = append(itemList, item("ds/"+ds.Name))
+	}
+
+	jobs, err := clientset.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fail(err)
+	}
+	for _, job := range jobs.Items {
+		itemList = append(itemList, item("job/"+job.Name))
+	}
+
+	return itemList
+}
+
+// ResolveLogSelector turns the aggregated log prompt into a label selector. It
+// accepts a plain selector or deploy/NAME, sts/NAME, ds/NAME and job/NAME.
+func ResolveLogSelector(clientset *kubernetes.Clientset, namespace string, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	kind, name, found := strings.Cut(ref, "/")
+	if !found || strings.ContainsAny(ref, "=!(,") {
+		return ref, nil
+	}
+	if namespace == metav1.NamespaceAll {
+		return "", fmt.Errorf("%s is looked up in one namespace, pick one or give a label selector", ref)
+	}
+
+	var selector *metav1.LabelSelector
+	switch kind {
+	case "deploy", "deployment":
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = deployment.Spec.Selector
+	case "sts", "statefulset":
+		statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = statefulSet.Spec.Selector
+	case "ds", "daemonset":
+		daemonSet, err := clientset.AppsV1().DaemonSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = daemonSet.Spec.Selector
+	case "job":
+		job, err := clientset.BatchV1().Jobs(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = job.Spec.Selector
+	default:
+		// Prefixed label keys such as app.kubernetes.io/name are selectors too
+		return ref, nil
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", err
+	}
+
+	return labelSelector.String(), nil
+}
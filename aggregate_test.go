@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestMergeLogLines(t *testing.T) {
+	at := func(second int, text string) logLine {
+		return logLine{timestamp: time.Unix(int64(second), 0), pod: "p", container: "c", text: text}
+	}
+
+	tests := []struct {
+		name        string
+		lines       []list.Item
+		batch       []logLine
+		maxLines    int
+		maxBytes    int
+		want        []string
+		wantDropped int
+	}{
+		{name: "empty", batch: []logLine{at(2, "b"), at(1, "a")}, maxLines: 10, maxBytes: 100, want: []string{"a", "b"}},
+		{name: "interleaved", lines: []list.Item{at(1, "a"), at(3, "c")}, batch: []logLine{at(4, "d"), at(2, "b")}, maxLines: 10, maxBytes: 100, want: []string{"a", "b", "c", "d"}},
+		{name: "same time keeps arrival order", lines: []list.Item{at(1, "a")}, batch: []logLine{at(1, "b"), at(1, "c")}, maxLines: 10, maxBytes: 100, want: []string{"a", "b", "c"}},
+		{name: "notices stay in place", lines: []list.Item{at(1, "a"), item("notice")}, batch: []logLine{at(2, "b")}, maxLines: 10, maxBytes: 100, want: []string{"a", "notice", "b"}},
+		{name: "line limit", lines: []list.Item{at(1, "a"), at(2, "b")}, batch: []logLine{at(3, "c")}, maxLines: 2, maxBytes: 100, want: []string{"b", "c"}, wantDropped: 1},
+		// Sizes count the "[p/c] " tag, 6 bytes per line
+		{name: "byte limit", lines: []list.Item{at(1, "aaaa")}, batch: []logLine{at(2, "bb"), at(3, "cc")}, maxLines: 10, maxBytes: 16, want: []string{"bb", "cc"}, wantDropped: 1},
+		{name: "newest line is kept", batch: []logLine{at(1, "aaaa")}, maxLines: 10, maxBytes: 1, want: []string{"aaaa"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, dropped := mergeLogLines(tt.lines, tt.batch, tt.maxLines, tt.maxBytes)
+
+			var got []string
+			for _, line := range merged {
+				if l, ok := line.(logLine); ok {
+					got = append(got, l.text)
+				} else {
+					got = append(got, itemText(line))
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("mergeLogLines() = %q, want %q", got, tt.want)
+			}
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
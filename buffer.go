@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+var (
+	logMaxLines = flag.Int("log-max-lines", 50000, "maximum number of log lines kept in memory per log view")
+	logMaxBytes = flag.Int("log-max-bytes", 32<<20, "maximum number of log bytes kept in memory per log view")
+)
+
+// logBuffer is a ring buffer of log lines bounded by both a line count and a
+// byte size. Once either limit is hit the oldest lines are dropped.
+type logBuffer struct {
+	lines    []string
+	start    int
+	count    int
+	bytes    int
+	maxLines int
+	maxBytes int
+	dropped  int
+}
+
+func newLogBuffer(maxLines int, maxBytes int) *logBuffer {
+	return &logBuffer{maxLines: max(maxLines, 1), maxBytes: max(maxBytes, 1)}
+}
+
+func (b *logBuffer) push(line string) {
+	for b.count > 0 && (b.count >= b.maxLines || b.bytes+len(line) > b.maxBytes) {
+		b.dropOldest()
+	}
+
+	switch {
+	case b.count < len(b.lines):
+		b.lines[(b.start+b.count)%len(b.lines)] = line
+	case b.start == 0:
+		b.lines = append(b.lines, line)
+	default:
+		// The ring is full but still below maxLines, so unroll it before growing
+		b.lines = append(b.snapshot(), line)
+		b.start = 0
+	}
+
+	b.count++
+	b.bytes += len(line)
+}
+
+func (b *logBuffer) dropOldest() {
+	b.bytes -= len(b.lines[b.start])
+	b.lines[b.start] = ""
+	b.start = (b.start + 1) % len(b.lines)
+	b.count--
+	b.dropped++
+}
+
+// snapshot returns the buffered lines from oldest to newest.
+func (b *logBuffer) snapshot() []string {
+	lines := make([]string, 0, b.count)
+	for n := 0; n < b.count; n++ {
+		lines = append(lines, b.lines[(b.start+n)%len(b.lines)])
+	}
+
+	return lines
+}
+
+// droppedNotice is shown in place of the lines that no longer fit.
+func (b *logBuffer) droppedNotice() string {
+	return fmt.Sprintf("[kuco] %d older lines dropped (limits: %d lines, %s)", b.dropped, b.maxLines, humanBytes(int64(b.maxBytes)))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLogBuffer(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxLines    int
+		maxBytes    int
+		push        []string
+		want        []string
+		wantDropped int
+	}{
+		{name: "below limits", maxLines: 5, maxBytes: 100, push: []string{"a", "b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "line limit", maxLines: 3, maxBytes: 100, push: []string{"a", "b", "c", "d", "e"}, want: []string{"c", "d", "e"}, wantDropped: 2},
+		{name: "wraps around twice", maxLines: 2, maxBytes: 100, push: []string{"a", "b", "c", "d", "e", "f", "g"}, want: []string{"f", "g"}, wantDropped: 5},
+		{name: "byte limit", maxLines: 10, maxBytes: 6, push: []string{"aa", "bb", "cc", "dd"}, want: []string{"bb", "cc", "dd"}, wantDropped: 1},
+		{name: "grows after wrapping", maxLines: 4, maxBytes: 6, push: []string{"aaa", "bbb", "c", "d", "e"}, want: []string{"bbb", "c", "d", "e"}, wantDropped: 1},
+		{name: "line over byte limit", maxLines: 10, maxBytes: 2, push: []string{"a", "bbbb"}, want: []string{"bbbb"}, wantDropped: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newLogBuffer(tt.maxLines, tt.maxBytes)
+			for _, line := range tt.push {
+				b.push(line)
+			}
+
+			if got := b.snapshot(); !slices.Equal(got, tt.want) {
+				t.Errorf("snapshot() = %q, want %q", got, tt.want)
+			}
+			if b.dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", b.dropped, tt.wantDropped)
+			}
+			if b.bytes != len(strings.Join(tt.want, "")) {
+				t.Errorf("bytes = %d, want %d", b.bytes, len(strings.Join(tt.want, "")))
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkParallelism bounds how many API calls a bulk action runs at once.
+const bulkParallelism = 5
+
+type bulkResult struct {
+	target string
+	output string
+	err    error
+}
+
+// bulkResultMsg is sent once every target of a bulk action has finished. A
+// bulk exec carries the session it ran in, so abandoned runs can be told apart.
+type bulkResultMsg struct {
+	action  string
+	session *execSession
+	results []bulkResult
+}
+
+// runBulk calls fn for every target with at most bulkParallelism calls in
+// flight. Results are returned in the same order as the targets.
+func runBulk(targets []string, fn func(target string) (string, error)) []bulkResult {
+	results := make([]bulkResult, len(targets))
+	sem := make(chan struct{}, bulkParallelism)
+
+	var wg sync.WaitGroup
+	for n, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			output, err := fn(target)
+			results[n] = bulkResult{target: target, output: output, err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func bulkCmd(action string, targets []string, fn func(target string) (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		return bulkResultMsg{action: action, results: runBulk(targets, fn)}
+	}
+}
+
+// bulkResultItems renders one row per target: status, target and the first
+// line of its output or error.
+func bulkResultItems(results []bulkResult) []list.Item {
+	width := 0
+	for _, result := range results {
+		width = max(width, len(result.target))
+	}
+
+	var itemList []list.Item
+	for _, result := range results {
+		status, detail := "OK", result.output
+		if result.err != nil {
+			status, detail = "FAILED", result.err.Error()
+		}
+		detail, _, _ = strings.Cut(strings.TrimSpace(detail), "\n")
+
+		itemList = append(itemList, item(fmt.Sprintf("%-6s  %-*s  %s", status, width, result.target, detail)))
+	}
+
+	return itemList
+}
+
+// bulkLogItems merges the logs of every target into one list, prefixing each
+// line with the pod it came from.
+func bulkLogItems(results []bulkResult) []list.Item {
+	var itemList []list.Item
+	for _, result := range results {
+		if result.err != nil {
+			itemList = append(itemList, item(fmt.Sprintf("%s | FAILED: %s", result.target, result.err.Error())))
+			continue
+		}
+
+		for _, line := range strings.Split(strings.TrimRight(result.output, "\n"), "\n") {
+			itemList = append(itemList, item(fmt.Sprintf("%s | %s", result.target, line)))
+		}
+	}
+
+	return itemList
+}
+
+// selectedTargets returns the marked items of the current list, or the
+// selected item when nothing is marked.
+func selectedTargets(m model) []string {
+	var targets []string
+	for _, listItem := range m.displayList.Items() {
+		i, ok := listItem.(item)
+		if ok && m.marked[string(i)] {
+			targets = append(targets, string(i))
+		}
+	}
+
+	if len(targets) == 0 {
+		i, ok := m.displayList.SelectedItem().(item)
+		if ok {
+			targets = append(targets, string(i))
+		}
+	}
+
+	return targets
+}
+
+// keepMarks forgets the marks of entries that are not in itemList.
+func keepMarks(marked map[string]bool, itemList []list.Item) {
+	listed := map[string]bool{}
+	for _, listItem := range itemList {
+		if i, ok := listItem.(item); ok {
+			listed[string(i)] = true
+		}
+	}
+
+	for name := range marked {
+		if !listed[name] {
+			delete(marked, name)
+		}
+	}
+}
+
+// setMarkedStatus shows the number of marked entries next to the item count
+// in the list's status bar.
+func setMarkedStatus(l *list.Model, marked int) {
+	if marked == 0 {
+		l.SetStatusBarItemName("item", "items")
+		return
+	}
+
+	suffix := fmt.Sprintf(" • %d marked", marked)
+	l.SetStatusBarItemName("item"+suffix, "items"+suffix)
+}
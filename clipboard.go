@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// clipboardMsg reports how a copy to the clipboard went. done is shown when it
+// worked.
+type clipboardMsg struct {
+	done string
+	err  error
+}
+
+// copyToClipboard sets the local system clipboard and the terminal's
+// clipboard through an OSC 52 escape sequence, which also works over SSH. The
+// sequence goes to stdout, where the TUI is drawn. The copy only fails when
+// neither way worked.
+func copyToClipboard(text string, done string) tea.Cmd {
+	return func() tea.Msg {
+		seq := osc52.New(text)
+		if os.Getenv("TMUX") != "" {
+			seq = seq.Tmux()
+		} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+			seq = seq.Screen()
+		}
+
+		localErr := clipboard.WriteAll(text)
+		_, terminalErr := seq.WriteTo(os.Stdout)
+		if localErr != nil && terminalErr != nil {
+			return clipboardMsg{err: errors.Join(localErr, terminalErr)}
+		}
+
+		return clipboardMsg{done: done}
+	}
+}
+
+// itemText returns the text a list entry is displayed with.
+func itemText(listItem list.Item) string {
+	switch i := listItem.(type) {
+	case item:
+		return string(i)
+	case logLine:
+		return i.tag() + " " + i.text
+	case containerItem:
+		return i.Title()
+	case fileEntry:
+		return i.Title()
+	case snippet:
+		return i.Command
+	case nodeItem:
+		return i.Title()
+	}
+
+	return listItem.FilterValue()
+}
+
+// yankText returns the selected line, or every line between the range start
+// and the cursor when a range has been started in the current view.
+func yankText(m model) string {
+	visible := m.displayList.VisibleItems()
+	cursor := m.displayList.Index()
+	if cursor < 0 || cursor >= len(visible) {
+		return ""
+	}
+
+	start, end := cursor, cursor
+	if m.rangeActive && m.rangeView == m.currentView {
+		start, end = min(m.rangeStart, cursor), max(m.rangeStart, cursor)
+		end = min(end, len(visible)-1)
+	}
+
+	var lines []string
+	for _, listItem := range visible[start : end+1] {
+		lines = append(lines, itemText(listItem))
+	}
+
+	return strings.Join(lines, "\n")
+}
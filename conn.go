@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	kubeconfigPath  = flag.String("kubeconfig", "~/.kube/config", "path to the kubeconfig file")
+	kubeContextName = flag.String("context", "", "kubeconfig context to use instead of the current one")
+)
+
+// kubeConnection is the resolved config and clientset of the active context.
+// The model owns it and passes it to listing, logs, exec, attach and
+// port-forward, so they always talk to the same cluster.
+type kubeConnection struct {
+	config      *rest.Config
+	clientset   *kubernetes.Clientset
+	contextName string
+}
+
+// connect resolves the kubeconfig and context from the flags. Without a
+// kubeconfig kuco falls back to the service account of the pod it runs in.
+func connect(kubeconfig string, contextName string) (*kubeConnection, error) {
+	loadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		inCluster, inClusterErr := rest.InClusterConfig()
+		if inClusterErr != nil {
+			return nil, fmt.Errorf("loading kubeconfig %s: %v", kubeconfig, err)
+		}
+		config, contextName = inCluster, "in-cluster"
+	} else if contextName == "" {
+		if rawConfig, err := clientConfig.RawConfig(); err == nil {
+			contextName = rawConfig.CurrentContext
+		}
+	}
+
+	clientset, err := GetClientsetFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kubeConnection{config: config, clientset: clientset, contextName: contextName}, nil
+}
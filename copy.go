@@ -0,0 +1,202 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// expandHome expands a leading ~ to the home directory. Without a home
+// directory the path is left as it is and fails where it is used.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, rest)
+}
+
+// DownloadFromContainer copies a file or directory out of the container by
+// streaming a tar archive over exec. An existing local directory receives the
+// copy inside it, otherwise localPath names the copy.
+func DownloadFromContainer(conn *kubeConnection, namespace string, podName string, containerName string, remotePath string, localPath string, onProgress func(int64)) (int64, error) {
+	base := path.Base(remotePath)
+	localPath = expandHome(localPath)
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, base)
+	}
+
+	reader, writer := io.Pipe()
+	var stderr bytes.Buffer
+	var execErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		execErr = StreamExecToPod(context.TODO(), conn, []string{"tar", "cf", "-", "-C", path.Dir(remotePath), "--", base}, containerName, podName, namespace, nil, writer, &stderr)
+		writer.CloseWithError(execErr)
+	}()
+
+	counter := &progressWriter{w: io.Discard, onProgress: onProgress}
+	err := untar(io.TeeReader(reader, counter), base, localPath)
+	if err == nil {
+		// Let tar finish writing the padding after the end of the archive
+		io.Copy(io.Discard, reader)
+	}
+	reader.CloseWithError(err)
+	<-done
+
+	// A local failure breaks the stream and is the cause of the remote error
+	if err != nil && (execErr == nil || !errors.Is(err, execErr)) {
+		return counter.written, err
+	}
+	if execErr != nil {
+		return counter.written, execFailure("tar", containerName, stderr.String(), execErr)
+	}
+
+	return counter.written, nil
+}
+
+// untar extracts an archive of base to dest, refusing entries outside of it.
+func untar(r io.Reader, base string, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if name != base && !strings.HasPrefix(name, base+"/") {
+			return fmt.Errorf("unexpected path %q in archive", header.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(strings.TrimPrefix(name, base)))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := writeFile(target, tr, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		// Links and devices are skipped, like kubectl cp does
+	}
+}
+
+func writeFile(target string, r io.Reader, perm fs.FileMode) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// UploadToContainer copies a local file or directory into remoteDir by piping
+// a tar archive into tar running in the container.
+func UploadToContainer(conn *kubeConnection, namespace string, podName string, containerName string, localPath string, remoteDir string, onProgress func(int64)) (int64, error) {
+	localPath = filepath.Clean(expandHome(localPath))
+	if _, err := os.Stat(localPath); err != nil {
+		return 0, err
+	}
+
+	reader, writer := io.Pipe()
+	counter := &progressWriter{w: writer, onProgress: onProgress}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.CloseWithError(writeTar(counter, localPath))
+	}()
+
+	var stderr bytes.Buffer
+	err := StreamExecToPod(context.TODO(), conn, []string{"tar", "xf", "-", "-C", remoteDir}, containerName, podName, namespace, reader, io.Discard, &stderr)
+	reader.CloseWithError(err)
+	<-done
+
+	if err != nil {
+		return counter.written, execFailure("tar", containerName, stderr.String(), err)
+	}
+
+	return counter.written, nil
+}
+
+// writeTar archives localPath under its base name.
+func writeTar(w io.Writer, localPath string) error {
+	tw := tar.NewWriter(w)
+	parent := filepath.Dir(localPath)
+
+	err := filepath.WalkDir(localPath, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(parent, file)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
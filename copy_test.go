@@ -0,0 +1,66 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUntar(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr bool
+		want    []string
+	}{
+		{name: "directory", entries: []string{"app/", "app/conf/", "app/conf/a.yaml"}, want: []string{"conf/a.yaml"}},
+		{name: "single file", entries: []string{"app"}, want: []string{""}},
+		{name: "cleaned inside base", entries: []string{"app/conf/../b.txt"}, want: []string{"b.txt"}},
+		{name: "parent of base", entries: []string{"app/../../etc"}, wantErr: true},
+		{name: "sibling of base", entries: []string{"app/../etc/passwd"}, wantErr: true},
+		{name: "shared prefix", entries: []string{"application/x"}, wantErr: true},
+		{name: "absolute", entries: []string{"/etc/passwd"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archive bytes.Buffer
+			tw := tar.NewWriter(&archive)
+			for _, name := range tt.entries {
+				header := &tar.Header{Name: name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(name))}
+				if name[len(name)-1] == '/' {
+					header = &tar.Header{Name: name, Mode: 0o755, Typeflag: tar.TypeDir}
+				}
+				if err := tw.WriteHeader(header); err != nil {
+					t.Fatal(err)
+				}
+				if header.Typeflag == tar.TypeReg {
+					tw.Write([]byte(name))
+				}
+			}
+			tw.Close()
+
+			root := t.TempDir()
+			dest := filepath.Join(root, "out")
+			err := untar(&archive, "app", dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("untar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, name := range tt.want {
+				if _, err := os.Stat(filepath.Join(dest, name)); err != nil {
+					t.Errorf("%q was not extracted: %v", name, err)
+				}
+			}
+			if tt.wantErr {
+				// Nothing may land next to the destination
+				found, _ := os.ReadDir(root)
+				if len(found) > 0 {
+					t.Errorf("untar() wrote %s outside of the destination", found[0].Name())
+				}
+			}
+		})
+	}
+}
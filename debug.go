@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultDebugImage is used when the debug prompt is left empty.
+const defaultDebugImage = "busybox"
+
+// debugStartTimeout bounds how long we wait for the debug image to be pulled and started.
+const debugStartTimeout = 2 * time.Minute
+
+// debugReadyMsg is sent once a debug container is running, or failed to start.
+// It names the pod the container was added to, which may no longer be the
+// current one.
+type debugReadyMsg struct {
+	namespace string
+	pod       string
+	container string
+	err       error
+}
+
+// parseDebugSpec splits "IMAGE [--target]" from the debug prompt.
+func parseDebugSpec(spec string) (string, bool) {
+	image, target := defaultDebugImage, false
+	for _, field := range strings.Fields(spec) {
+		if field == "--target" || field == "-t" {
+			target = true
+		} else {
+			image = field
+		}
+	}
+
+	return image, target
+}
+
+// CreateDebugContainer adds an ephemeral container running image to a pod. When
+// targetContainer is set the debug container shares its process namespace.
+func CreateDebugContainer(clientset *kubernetes.Clientset, namespace string, podName string, image string, targetContainer string) (string, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	name := "kuco-debug-" + utilrand.String(5)
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: targetContainer,
+	})
+
+	_, err = clientset.CoreV1().Pods(namespace).UpdateEphemeralContainers(context.TODO(), podName, pod, metav1.UpdateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("adding ephemeral container: %v", err)
+	}
+
+	return name, nil
+}
+
+// WaitForEphemeralContainer polls until the container runs, giving up early
+// when it terminates or its image cannot be pulled.
+func WaitForEphemeralContainer(clientset *kubernetes.Clientset, namespace string, podName string, containerName string) error {
+	return wait.PollUntilContextTimeout(context.TODO(), time.Second, debugStartTimeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != containerName {
+				continue
+			}
+
+			switch {
+			case status.State.Running != nil:
+				return true, nil
+			case status.State.Terminated != nil:
+				return false, fmt.Errorf("debug container exited: %s", status.State.Terminated.Reason)
+			case status.State.Waiting != nil:
+				switch status.State.Waiting.Reason {
+				case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerError":
+					return false, fmt.Errorf("%s: %s", status.State.Waiting.Reason, status.State.Waiting.Message)
+				}
+			}
+		}
+
+		return false, nil
+	})
+}
+
+// debugCmd adds the debug container and waits for it in the background.
+func debugCmd(clientset *kubernetes.Clientset, namespace string, podName string, image string, targetContainer string) tea.Cmd {
+	return func() tea.Msg {
+		name, err := CreateDebugContainer(clientset, namespace, podName, image, targetContainer)
+		if err == nil {
+			err = WaitForEphemeralContainer(clientset, namespace, podName, name)
+		}
+
+		return debugReadyMsg{namespace: namespace, pod: podName, container: name, err: err}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -10,20 +11,50 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-type itemDelegate struct{}
+type itemDelegate struct {
+	marked    map[string]bool
+	columns   map[string]columnRow
+	highlight *regexp.Regexp
+	raw       bool
+}
 
 func (d itemDelegate) Height() int                             { return 1 }
 func (d itemDelegate) Spacing() int                            { return 0 }
 func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
-	i, ok := listItem.(item)
-	if !ok {
+	var name, str string
+	fn := itemStyle.Render
+	switch i := listItem.(type) {
+	case logLine:
+		str = d.line(i)
+	case item:
+		name, str = string(i), d.format(string(i))
+		if row, ok := d.columns[name]; ok {
+			str = row.text
+			if row.alert {
+				fn = alertItemStyle.Render
+			}
+		}
+	case containerItem:
+		name, str = i.Name, i.Title()
+	case fileEntry:
+		name, str = i.name, i.Title()
+	case snippet:
+		name, str = i.Name, i.Title()
+	case nodeItem:
+		name, str = i.name, i.Title()
+	case serviceItem:
+		name, str = i.name, i.Title()
+	case treeRow:
+		name, str = i.path, i.Title()
+	default:
 		return
 	}
 
-	str := fmt.Sprintf("%s", i)
-
-	fn := itemStyle.Render
+	if d.marked[name] {
+		str = "* " + str
+		fn = markedItemStyle.Render
+	}
 	if index == m.Index() {
 		fn = func(s ...string) string {
 			return selectedItemStyle.Render("> " + strings.Join(s, " "))
@@ -33,6 +64,42 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	fmt.Fprint(w, fn(str))
 }
 
+// format applies structured log columns and search highlighting to a line.
+// JSON and logfmt lines are detected on their own unless raw lines are shown.
+func (d itemDelegate) format(str string) string {
+	highlight := func(text string) string {
+		if d.highlight == nil {
+			return text
+		}
+		return d.highlight.ReplaceAllStringFunc(text, func(match string) string {
+			return matchStyle.Render(match)
+		})
+	}
+
+	if !d.raw {
+		prefix, text := "", str
+		if _, rest, ok := splitTimestamp(str); ok {
+			prefix, text = str[:len(str)-len(rest)], rest
+		}
+		if parsed, ok := parseStructured(text); ok {
+			return highlight(prefix) + parsed.pretty(highlight, true)
+		}
+	}
+
+	return highlight(str)
+}
+
+// line formats an item for the log viewport. Aggregated log lines keep their
+// colored pod/container tag in front of the formatted text.
+func (d itemDelegate) line(listItem list.Item) string {
+	if line, ok := listItem.(logLine); ok {
+		tag := line.tag()
+		return tagStyle(tag).Render(tag) + " " + d.format(line.text)
+	}
+
+	return d.format(itemText(listItem))
+}
+
 //
 // Example Code
 //
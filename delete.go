@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxListedTargets limits how many targets the dialog spells out.
+const maxListedTargets = 8
+
+var propagationPolicies = []metav1.DeletionPropagation{
+	metav1.DeletePropagationBackground,
+	metav1.DeletePropagationForeground,
+	metav1.DeletePropagationOrphan,
+}
+
+// deleteModal holds the state of the delete confirmation dialog.
+type deleteModal struct {
+	active      bool
+	kind        string
+	namespace   string
+	targets     []string
+	gracePeriod textinput.Model
+	propagation int
+	force       bool
+}
+
+func newDeleteModal(kind string, namespace string, targets []string) deleteModal {
+	ti := textinput.New()
+	ti.Placeholder = "default"
+	ti.Focus()
+	ti.CharLimit = 6
+	ti.Width = 8
+	ti.Validate = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err
+	}
+
+	return deleteModal{
+		active:      true,
+		kind:        kind,
+		namespace:   namespace,
+		targets:     targets,
+		gracePeriod: ti,
+	}
+}
+
+// options builds the DeleteOptions selected in the dialog. Force deletion
+// always uses a grace period of zero, mirroring `kubectl delete --force`.
+func (d deleteModal) options() metav1.DeleteOptions {
+	policy := propagationPolicies[d.propagation]
+	opts := metav1.DeleteOptions{PropagationPolicy: &policy}
+
+	if d.force {
+		var zero int64
+		opts.GracePeriodSeconds = &zero
+	} else if seconds, err := strconv.ParseInt(d.gracePeriod.Value(), 10, 64); err == nil {
+		opts.GracePeriodSeconds = &seconds
+	}
+
+	return opts
+}
+
+func (d deleteModal) View(contextName string) string {
+	var b strings.Builder
+
+	scope := ""
+	if d.kind != "namespace" && d.namespace != "" {
+		scope = fmt.Sprintf(" in namespace %q", d.namespace)
+	}
+	fmt.Fprintf(&b, "Delete %d %s(s)%s on context %q?\n\n", len(d.targets), d.kind, scope, contextName)
+	for n, target := range d.targets {
+		if n == maxListedTargets {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(d.targets)-n)
+			break
+		}
+		fmt.Fprintf(&b, "  %s/%s\n", d.kind, target)
+	}
+
+	force := "off"
+	if d.force {
+		force = "on (grace period 0)"
+	}
+	fmt.Fprintf(&b, "\nGrace period (s): %s\n", d.gracePeriod.View())
+	fmt.Fprintf(&b, "Propagation: %s\n", propagationPolicies[d.propagation])
+	fmt.Fprintf(&b, "Force: %s\n\n", force)
+	b.WriteString("[y] delete  [n/esc] cancel  [p] propagation  [f] force")
+
+	return modalStyle.Render(b.String())
+}
+
+// deleteCmd deletes every target of the dialog concurrently.
+func deleteCmd(conn *kubeConnection, d deleteModal) tea.Cmd {
+	opts := d.options()
+
+	return bulkCmd("Delete", d.targets, func(target string) (string, error) {
+		namespace, name := splitTarget(d.namespace, target)
+		err := DeleteResource(conn, d.kind, namespace, name, opts)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s/%s deleted", d.kind, target), nil
+	})
+}
+
+// updateDeleteModal handles input while the delete dialog is open.
+func (m model) updateDeleteModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.confirm):
+		m.deleteModal.active = false
+		m.resultsParentView = m.currentView
+		cmd := m.displayList.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Deleting %d %s(s)...", len(m.deleteModal.targets), m.deleteModal.kind)))
+		return m, tea.Batch(cmd, deleteCmd(m.conn, m.deleteModal))
+
+	case key.Matches(msg, m.keys.cancel):
+		m.deleteModal.active = false
+		return m, nil
+
+	case key.Matches(msg, m.keys.propagation):
+		m.deleteModal.propagation = (m.deleteModal.propagation + 1) % len(propagationPolicies)
+		return m, nil
+
+	case key.Matches(msg, m.keys.force):
+		m.deleteModal.force = !m.deleteModal.force
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.deleteModal.gracePeriod, cmd = m.deleteModal.gracePeriod.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var execTimeout = flag.Duration("exec-timeout", time.Minute, "how long an exec command may run before it is aborted, 0 for no limit")
+
+// execSession is a command running in the background. Its output is delivered
+// line by line as it arrives; err is set before output is closed. The whole
+// output is also spooled to a temporary file, as the view only keeps the end.
+type execSession struct {
+	cancel context.CancelFunc
+	output chan string
+	err    error
+	spool  string
+}
+
+// execOutputMsg carries the lines a running command printed since the last one.
+type execOutputMsg struct {
+	session *execSession
+	lines   []string
+}
+
+// execDoneMsg is sent once a running command has exited.
+type execDoneMsg struct {
+	session *execSession
+	err     error
+}
+
+// execContext bounds a command by the configured timeout.
+func execContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if *execTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, *execTimeout)
+}
+
+// startExec runs command in a container, streaming stdout and stderr together.
+func startExec(conn *kubeConnection, command string, containerName string, podName string, namespace string) *execSession {
+	ctx, cancel := execContext(context.Background())
+	s := &execSession{cancel: cancel, output: make(chan string, 256)}
+	w := &lineWriter{ctx: ctx, lines: s.output}
+	if f, err := os.CreateTemp("", "kuco-exec-*.log"); err == nil {
+		s.spool, w.spool = f.Name(), f
+	}
+
+	go func() {
+		defer cancel()
+
+		err := StreamExecToPod(ctx, conn, strings.Fields(command), containerName, podName, namespace, nil, w, w)
+		w.flush()
+		if w.spool != nil {
+			w.spool.Close()
+		}
+
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("timed out after %s", *execTimeout)
+		case errors.Is(ctx.Err(), context.Canceled):
+			err = fmt.Errorf("cancelled")
+		}
+		s.err = err
+		close(s.output)
+	}()
+
+	return s
+}
+
+// wait returns the lines printed so far, blocking until there is at least one.
+func (s *execSession) wait() tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-s.output
+		if !ok {
+			return execDoneMsg{session: s, err: s.err}
+		}
+
+		lines := []string{line}
+		for len(lines) < 1000 {
+			select {
+			case line, ok := <-s.output:
+				if !ok {
+					return execOutputMsg{session: s, lines: lines}
+				}
+				lines = append(lines, line)
+			default:
+				return execOutputMsg{session: s, lines: lines}
+			}
+		}
+
+		return execOutputMsg{session: s, lines: lines}
+	}
+}
+
+// lineWriter splits what is written to it into lines. Stdout and stderr share
+// one writer, so writes are serialised. Lines are dropped once ctx is done, as
+// nobody is reading them any more; the spool, if any, still gets everything.
+type lineWriter struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	partial []byte
+	lines   chan<- string
+	spool   *os.File
+}
+
+func (w *lineWriter) send(line string) {
+	select {
+	case w.lines <- line:
+	case <-w.ctx.Done():
+	}
+}
+
+func (w *lineWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.spool != nil {
+		if _, err := w.spool.Write(b); err != nil {
+			w.spool.Close()
+			w.spool = nil
+		}
+	}
+	w.partial = append(w.partial, b...)
+	for {
+		end := bytes.IndexByte(w.partial, '\n')
+		if end < 0 {
+			break
+		}
+
+		w.send(strings.TrimSuffix(string(w.partial[:end]), "\r"))
+		w.partial = w.partial[end+1:]
+	}
+
+	return len(b), nil
+}
+
+// flush sends a last line that did not end in a newline.
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.send(string(w.partial))
+		w.partial = nil
+	}
+}
+
+// dropExecSpool removes the full output of the last command.
+func (m *model) dropExecSpool() {
+	if m.execSpool != "" {
+		os.Remove(m.execSpool)
+		m.execSpool = ""
+	}
+}
+
+// stopExec aborts the running command, if any.
+func (m *model) stopExec() {
+	if m.exec != nil {
+		m.exec.cancel()
+		m.exec = nil
+	}
+}
+
+// runExec runs command in the current container, or in every exec target when
+// the exec was started from the Pods view, and remembers it in the history.
+func (m model) runExec(command string) (tea.Model, tea.Cmd) {
+	var historyCmd tea.Cmd
+	if err := m.history.add(command); err != nil {
+		historyCmd = m.displayList.NewStatusMessage(statusMessageStyle("Saving exec history failed: " + err.Error()))
+	}
+
+	m.stopExec()
+	if len(m.execTargets) > 0 {
+		namespace := m.currentNamespace
+		conn := m.conn
+		clientset := conn.clientset
+		targets, fixedContainer := m.execTargets, m.execContainer
+		ctx, cancel := context.WithCancel(context.Background())
+		session := &execSession{cancel: cancel}
+		m.exec = session
+		m.resultsParentView = 1
+
+		status := m.displayList.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Running in %d pod(s), %s cancels", len(m.execTargets), m.keys.cancelExec.Help().Key)))
+		run := func(target string) (string, error) {
+			namespace, target := splitTarget(namespace, target)
+			containerName := fixedContainer
+			if containerName == "" {
+				var err error
+				containerName, err = DefaultContainer(clientset, namespace, target)
+				if err != nil {
+					return "", err
+				}
+			}
+
+			execCtx, cancel := execContext(ctx)
+			defer cancel()
+
+			output, stderr, err := ExecToPodThroughAPI(execCtx, conn, command, containerName, target, namespace, nil)
+			if execCtx.Err() != nil {
+				err = fmt.Errorf("%s: %w", execCtx.Err(), err)
+			}
+			return output + stderr, err
+		}
+		return m, tea.Batch(historyCmd, status, func() tea.Msg {
+			return bulkResultMsg{action: "Exec", session: session, results: runBulk(targets, run)}
+		})
+	}
+
+	m.execBytes = 0
+	m.execError = ""
+	m.currentView = 4
+	m.displayList = updateDisplayList(m, []list.Item{})
+	m.currentView = 5
+
+	m.dropExecSpool()
+	m.exec = startExec(m.conn, command, m.currentContainer, m.currentPod, m.currentNamespace)
+	m.execSpool = m.exec.spool
+	status := m.displayList.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Running, %s cancels", m.keys.cancelExec.Help().Key)))
+	return m, tea.Batch(historyCmd, status, m.exec.wait())
+}
+
+// searchHistory recalls the next older command matching query into the exec
+// input.
+func (m *model) searchHistory(query string) tea.Cmd {
+	command, ok := m.history.search(query)
+	if !ok {
+		return m.displayList.NewStatusMessage(statusMessageStyle("No older command matches " + query))
+	}
+	m.execInput.SetValue(command)
+	m.execInput.CursorEnd()
+
+	return nil
+}
+
+// appendExecOutput adds lines printed by the running command to the output
+// view. The oldest lines are dropped past the limits of a log view.
+func (m *model) appendExecOutput(lines []string) tea.Cmd {
+	itemList := m.displayList.Items()
+	for _, line := range lines {
+		itemList = append(itemList, item(line))
+		m.execBytes += len(line) + 1
+	}
+	for len(itemList) > 1 && (len(itemList) > *logMaxLines || m.execBytes > *logMaxBytes) {
+		m.execBytes -= len(itemText(itemList[0])) + 1
+		itemList = itemList[1:]
+	}
+
+	return m.displayList.SetItems(itemList)
+}
+
+// finishExec reports how the running command ended.
+func (m *model) finishExec(err error) tea.Cmd {
+	m.exec = nil
+	if err == nil {
+		return m.displayList.NewStatusMessage(statusMessageStyle("Command finished"))
+	}
+
+	message := fmt.Sprintf("Command failed in pod %q, container %q: %v", m.currentPod, m.currentContainer, err)
+	if m.execBytes == 0 {
+		m.execError = message
+	}
+	cmd := m.appendExecOutput([]string{"[kuco] " + message})
+
+	return tea.Batch(cmd, m.displayList.NewStatusMessage(statusMessageStyle(message)))
+}
+
+// snippetItemList lists the saved snippets, showing a broken file as an entry.
+func snippetItemList() []list.Item {
+	snippets, err := loadSnippets(*snippetsPath)
+	if err != nil {
+		return []list.Item{item(err.Error())}
+	}
+
+	itemList := []list.Item{}
+	for _, s := range snippets {
+		itemList = append(itemList, s)
+	}
+
+	return itemList
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// execExitCode returns the exit code of an exec, or -1 when the command could
+// not be run at all.
+func execExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus()
+	}
+
+	return -1
+}
+
+func exitColumn(code int) string {
+	if code < 0 {
+		return "ERR"
+	}
+
+	return fmt.Sprint(code)
+}
+
+// execOutput is what a fan-out exec printed, or why it could not run.
+func execOutput(result bulkResult) string {
+	if execExitCode(result.err) < 0 {
+		return result.err.Error()
+	}
+
+	return result.output
+}
+
+func firstLine(output string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	return line
+}
+
+// execResultItems renders one row per pod: exit code, pod and the first line
+// of its output.
+func execResultItems(results []bulkResult) []list.Item {
+	width := 0
+	for _, result := range results {
+		width = max(width, len(result.target))
+	}
+
+	var itemList []list.Item
+	for _, result := range results {
+		exit := exitColumn(execExitCode(result.err))
+		itemList = append(itemList, item(fmt.Sprintf("exit %-3s  %-*s  %s", exit, width, result.target, firstLine(execOutput(result)))))
+	}
+
+	return itemList
+}
+
+// resultGroup is a set of pods whose command exited and printed the same.
+type resultGroup struct {
+	exit    int
+	output  string
+	targets []string
+}
+
+// groupResults groups identical outputs, largest group first, so the pods
+// that drifted from the rest end up at the bottom.
+func groupResults(results []bulkResult) []resultGroup {
+	var groups []resultGroup
+	index := map[string]int{}
+	for _, result := range results {
+		exit, output := execExitCode(result.err), execOutput(result)
+		k := fmt.Sprintf("%d\x00%s", exit, output)
+
+		n, ok := index[k]
+		if !ok {
+			n = len(groups)
+			index[k] = n
+			groups = append(groups, resultGroup{exit: exit, output: output})
+		}
+		groups[n].targets = append(groups[n].targets, result.target)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		return len(groups[a].targets) > len(groups[b].targets)
+	})
+
+	return groups
+}
+
+func groupItems(groups []resultGroup) []list.Item {
+	var itemList []list.Item
+	for _, group := range groups {
+		pods := strings.Join(group.targets[:min(len(group.targets), 3)], ", ")
+		if len(group.targets) > 3 {
+			pods += ", …"
+		}
+
+		itemList = append(itemList, item(fmt.Sprintf("%3d pod(s)  exit %-3s  %s  (%s)", len(group.targets), exitColumn(group.exit), firstLine(group.output), pods)))
+	}
+
+	return itemList
+}
+
+// resultItems lists the results of the last bulk action the way they are
+// currently shown.
+func (m model) resultItems() []list.Item {
+	switch {
+	case m.resultsAction == "Exec" && m.resultsGrouped:
+		return groupItems(groupResults(m.bulkResults))
+	case m.resultsAction == "Exec":
+		return execResultItems(m.bulkResults)
+	}
+
+	return bulkResultItems(m.bulkResults)
+}
+
+// resultDetail returns the full output behind a row of the exec results.
+func (m model) resultDetail(n int) (string, []string) {
+	if m.resultsGrouped {
+		groups := groupResults(m.bulkResults)
+		if n >= len(groups) {
+			return "", nil
+		}
+
+		group := groups[n]
+		title := fmt.Sprintf("[KUCO] Exit %s in %d pod(s)", exitColumn(group.exit), len(group.targets))
+		lines := append([]string{"Pods: " + strings.Join(group.targets, ", "), ""}, strings.Split(strings.TrimRight(group.output, "\n"), "\n")...)
+		return title, lines
+	}
+
+	if n >= len(m.bulkResults) {
+		return "", nil
+	}
+	result := m.bulkResults[n]
+	title := fmt.Sprintf("[KUCO] %s (exit %s)", result.target, exitColumn(execExitCode(result.err)))
+	return title, strings.Split(strings.TrimRight(execOutput(result), "\n"), "\n")
+}
+
+// parseFanOutSpec splits "SELECTOR [-c CONTAINER]" from the fan-out prompt.
+func parseFanOutSpec(spec string) (string, string) {
+	selector, container := spec, ""
+	if before, after, found := strings.Cut(spec, " -c "); found {
+		selector, container = before, strings.TrimSpace(after)
+	}
+
+	return strings.TrimSpace(selector), container
+}
+
+// PodsMatching lists the names of the pods matched by a label selector.
+func PodsMatching(clientset *kubernetes.Clientset, namespace string, selector string) ([]string, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, pod := range pods.Items {
+		names = append(names, qualifiedName(namespace, pod.Namespace, pod.Name))
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no pods match %s", selector)
+	}
+
+	return names, nil
+}
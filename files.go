@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// previewMaxBytes bounds how much of a file the preview reads.
+const previewMaxBytes = 1 << 20
+
+// fileEntry is a row of the Files view.
+type fileEntry struct {
+	name     string
+	dir      bool
+	mode     string
+	size     string
+	modified string
+	link     string
+}
+
+func (f fileEntry) Title() string {
+	name := f.name
+	switch {
+	case f.name == "..":
+	case f.dir:
+		name += "/"
+	case f.link != "":
+		name += " -> " + f.link
+	}
+
+	return fmt.Sprintf("%-10s  %8s  %-19s  %s", f.mode, f.size, f.modified, name)
+}
+func (f fileEntry) FilterValue() string { return f.name }
+
+// ListFiles lists a directory of the container with ls -l. Full timestamps are
+// asked for first, falling back to the short format for ls builds without them.
+func ListFiles(ctx context.Context, conn *kubeConnection, namespace string, podName string, containerName string, dir string) ([]fileEntry, error) {
+	var stdout, stderr bytes.Buffer
+	err := StreamExecToPod(ctx, conn, []string{"ls", "-lA", "--full-time", "--", dir}, containerName, podName, namespace, nil, &stdout, &stderr)
+	if err != nil {
+		stdout.Reset()
+		stderr.Reset()
+		err = StreamExecToPod(ctx, conn, []string{"ls", "-lA", "--", dir}, containerName, podName, namespace, nil, &stdout, &stderr)
+	}
+	if err != nil {
+		return nil, execFailure("ls", containerName, stderr.String(), err)
+	}
+
+	var entries []fileEntry
+	if dir != "/" {
+		entries = append(entries, fileEntry{name: "..", dir: true})
+	}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if entry, ok := parseLsLine(line); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// parseLsLine parses a line of ls -l output. Both the full and the short time
+// formats take three fields; device files show "major, minor" as their size.
+func parseLsLine(line string) (fileEntry, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 9 || len(fields[0]) < 10 {
+		return fileEntry{}, false
+	}
+
+	entry := fileEntry{mode: fields[0], size: fields[4], dir: fields[0][0] == 'd'}
+	skip := 5
+	switch fields[0][0] {
+	case 'b', 'c':
+		entry.size = fields[4] + fields[5]
+		skip = 6
+	default:
+		if size, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+			entry.size = humanBytes(size)
+		}
+	}
+	if len(fields) < skip+4 {
+		return fileEntry{}, false
+	}
+
+	entry.modified = strings.Join(fields[skip:skip+3], " ")
+	if strings.Contains(fields[skip], "-") {
+		// Full time: date, time with fractions, zone
+		clock, _, _ := strings.Cut(fields[skip+1], ".")
+		entry.modified = fields[skip] + " " + clock
+	}
+
+	entry.name = cutFields(line, skip+3)
+	if fields[0][0] == 'l' {
+		entry.name, entry.link, _ = strings.Cut(entry.name, " -> ")
+	}
+
+	return entry, entry.name != ""
+}
+
+// cutFields drops the first n whitespace separated fields of line, keeping the
+// rest untouched so names with spaces survive.
+func cutFields(line string, n int) string {
+	rest := strings.TrimLeft(line, " ")
+	for ; n > 0; n-- {
+		end := strings.IndexByte(rest, ' ')
+		if end < 0 {
+			return ""
+		}
+		rest = strings.TrimLeft(rest[end:], " ")
+	}
+
+	return rest
+}
+
+// PreviewFile reads the start of a text file in the container.
+func PreviewFile(ctx context.Context, conn *kubeConnection, namespace string, podName string, containerName string, filePath string) ([]string, bool, error) {
+	var stdout, stderr bytes.Buffer
+	err := StreamExecToPod(ctx, conn, []string{"head", "-c", strconv.Itoa(previewMaxBytes + 1), "--", filePath}, containerName, podName, namespace, nil, &stdout, &stderr)
+	if err != nil {
+		return nil, false, execFailure("head", containerName, stderr.String(), err)
+	}
+
+	data := stdout.Bytes()
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil, false, fmt.Errorf("%s looks like a binary file, download it instead", filePath)
+	}
+
+	truncated := len(data) > previewMaxBytes
+	if truncated {
+		data = data[:previewMaxBytes]
+	}
+
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), truncated, nil
+}
+
+// execFailure explains why a tool run in a container failed, calling out
+// images that do not ship it at all.
+func execFailure(tool string, containerName string, stderr string, err error) error {
+	if strings.Contains(err.Error()+stderr, "executable file not found") {
+		return fmt.Errorf("%s is not available in container %s", tool, containerName)
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return fmt.Errorf("%s: %s", tool, stderr)
+	}
+
+	return fmt.Errorf("%s: %v", tool, err)
+}
+
+// filesMsg delivers a directory listing for the Files view.
+type filesMsg struct {
+	pod       string
+	container string
+	dir       string
+	entries   []fileEntry
+	err       error
+}
+
+// previewMsg delivers the start of a file for the pager.
+type previewMsg struct {
+	pod       string
+	container string
+	path      string
+	lines     []string
+	truncated bool
+	err       error
+}
+
+// listFilesCmd lists dir in the background, bounded by --exec-timeout.
+func listFilesCmd(conn *kubeConnection, namespace string, podName string, containerName string, dir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := execContext(context.Background())
+		defer cancel()
+
+		entries, err := ListFiles(ctx, conn, namespace, podName, containerName, dir)
+		return filesMsg{pod: podName, container: containerName, dir: dir, entries: entries, err: err}
+	}
+}
+
+// openFileCmd previews filePath in the background. Symlinks are followed into
+// directories when they point at one.
+func openFileCmd(conn *kubeConnection, namespace string, podName string, containerName string, filePath string, link bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := execContext(context.Background())
+		defer cancel()
+
+		if link {
+			if entries, err := ListFiles(ctx, conn, namespace, podName, containerName, filePath+"/"); err == nil {
+				return filesMsg{pod: podName, container: containerName, dir: filePath, entries: entries}
+			}
+		}
+
+		lines, truncated, err := PreviewFile(ctx, conn, namespace, podName, containerName, filePath)
+		return previewMsg{pod: podName, container: containerName, path: filePath, lines: lines, truncated: truncated, err: err}
+	}
+}
+
+// fileItems turns a listing into Files view rows, showing errors in place of
+// entries.
+func fileItems(entries []fileEntry, err error) []list.Item {
+	if err != nil {
+		return []list.Item{item(err.Error())}
+	}
+
+	itemList := []list.Item{}
+	for _, entry := range entries {
+		itemList = append(itemList, entry)
+	}
+
+	return itemList
+}
+
+// selectedPath returns the container path of the selected Files view entry.
+func (m model) selectedPath() (fileEntry, string, bool) {
+	entry, ok := m.displayList.SelectedItem().(fileEntry)
+	if !ok {
+		return fileEntry{}, "", false
+	}
+
+	return entry, path.Join(m.filesDir, entry.name), true
+}
+
+// openPreview shows the start of a text file in the pager.
+func (m model) openPreview(msg previewMsg) model {
+	lines := msg.lines
+	if msg.truncated {
+		lines = append(lines, fmt.Sprintf("[kuco] preview stops after %s, download the file to see the rest", humanBytes(previewMaxBytes)))
+	}
+
+	m.filesList = m.displayList
+	return m.openPager(fmt.Sprintf("[KUCO] %s:%s", msg.container, msg.path), lines, 9)
+}
+
+// openPager shows lines in the pager. Going back returns to parentView.
+func (m model) openPager(title string, lines []string, parentView int) model {
+	itemList := []list.Item{}
+	for _, line := range lines {
+		itemList = append(itemList, item(line))
+	}
+
+	m.pagerTitle = title
+	m.pagerParentView = parentView
+	m.logViewport = logViewport{wrap: m.logViewport.wrap}
+	m.currentView = 10 // switch to pager
+	m.displayList = updateDisplayList(m, itemList)
+
+	return m
+}
+
+// inPager reports whether the current view is rendered by the log viewport.
+func (m model) inPager() bool {
+	return m.currentView == 3 || m.currentView == 8 || m.currentView == 10
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestParseLsLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   fileEntry
+		wantOK bool
+	}{
+		{
+			name:   "full time",
+			line:   "-rw-r--r-- 1 root root 1536 2024-05-01 12:30:45.123456789 +0000 app.yaml",
+			want:   fileEntry{name: "app.yaml", mode: "-rw-r--r--", size: humanBytes(1536), modified: "2024-05-01 12:30:45"},
+			wantOK: true,
+		},
+		{
+			name:   "short time",
+			line:   "drwxr-xr-x    2 root     root          4096 May  1 12:30 conf",
+			want:   fileEntry{name: "conf", dir: true, mode: "drwxr-xr-x", size: humanBytes(4096), modified: "May 1 12:30"},
+			wantOK: true,
+		},
+		{
+			name:   "spaces in name",
+			line:   "-rw-r--r-- 1 root root 0 2024-05-01 12:30:45.000000000 +0000 my  file.txt",
+			want:   fileEntry{name: "my  file.txt", mode: "-rw-r--r--", size: humanBytes(0), modified: "2024-05-01 12:30:45"},
+			wantOK: true,
+		},
+		{
+			name:   "symlink",
+			line:   "lrwxrwxrwx 1 root root 7 2024-05-01 12:30:45.000000000 +0000 bin -> usr/bin",
+			want:   fileEntry{name: "bin", link: "usr/bin", mode: "lrwxrwxrwx", size: humanBytes(7), modified: "2024-05-01 12:30:45"},
+			wantOK: true,
+		},
+		{
+			name:   "device",
+			line:   "crw-rw-rw- 1 root root 1, 3 2024-05-01 12:30:45.000000000 +0000 null",
+			want:   fileEntry{name: "null", mode: "crw-rw-rw-", size: "1,3", modified: "2024-05-01 12:30:45"},
+			wantOK: true,
+		},
+		{name: "total", line: "total 12"},
+		{name: "empty", line: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseLsLine(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("parseLsLine() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseLsLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
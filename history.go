@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	execHistoryPath = flag.String("exec-history", "~/.kuco/exec_history", "file exec commands are remembered in, empty to keep them in memory only")
+	snippetsPath    = flag.String("snippets", "~/.kuco/snippets.yaml", "YAML list of named exec commands")
+)
+
+// maxHistory is how many exec commands are remembered.
+const maxHistory = 1000
+
+// execHistory holds the exec commands run so far, oldest first. pos is the
+// entry being recalled, len(entries) while editing a new command. While
+// searching, typing edits query instead of the command.
+type execHistory struct {
+	entries   []string
+	pos       int
+	query     string
+	searching bool
+	path      string
+}
+
+// loadExecHistory reads the history file, starting empty when there is none.
+func loadExecHistory(path string) *execHistory {
+	h := &execHistory{path: path}
+	if path == "" {
+		return h
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	h.entries = h.entries[max(len(h.entries)-maxHistory, 0):]
+	h.reset()
+
+	return h
+}
+
+// reset leaves recall and reverse search.
+func (h *execHistory) reset() {
+	h.pos = len(h.entries)
+	h.query = ""
+	h.searching = false
+}
+
+// add remembers a command, skipping repeats of the last one, and appends it
+// to the history file.
+func (h *execHistory) add(command string) error {
+	command = strings.TrimSpace(command)
+	defer h.reset()
+	if command == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == command) {
+		return nil
+	}
+
+	h.entries = append(h.entries, command)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, command)
+	return err
+}
+
+// previous steps back through the history, returning false at the oldest entry.
+func (h *execHistory) previous() (string, bool) {
+	h.query, h.searching = "", false
+	if h.pos == 0 {
+		return "", false
+	}
+
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// next steps forward, returning an empty command past the newest entry.
+func (h *execHistory) next() (string, bool) {
+	h.query, h.searching = "", false
+	if h.pos >= len(h.entries) {
+		return "", false
+	}
+
+	h.pos++
+	if h.pos == len(h.entries) {
+		return "", true
+	}
+	return h.entries[h.pos], true
+}
+
+// search finds the next older entry containing query, like ctrl+r in a shell.
+// Repeating the search with the same query continues from the last match.
+func (h *execHistory) search(query string) (string, bool) {
+	h.searching = true
+	if query != h.query {
+		h.query = query
+		h.pos = len(h.entries)
+	}
+
+	for n := h.pos - 1; n >= 0; n-- {
+		if strings.Contains(h.entries[n], query) {
+			h.pos = n
+			return h.entries[n], true
+		}
+	}
+
+	return "", false
+}
+
+// snippet is a named exec command.
+type snippet struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+func (s snippet) Title() string       { return fmt.Sprintf("%-24s  %s", s.Name, s.Command) }
+func (s snippet) FilterValue() string { return s.Name + " " + s.Command }
+
+// defaultSnippets are offered until a snippets file is written.
+var defaultSnippets = []snippet{
+	{Name: "show env", Command: "env"},
+	{Name: "check DNS", Command: "cat /etc/resolv.conf"},
+	{Name: "listening sockets", Command: "netstat -tln"},
+	{Name: "processes", Command: "ps aux"},
+	{Name: "disk usage", Command: "df -h"},
+}
+
+// loadSnippets reads the snippets file, falling back to the defaults.
+func loadSnippets(path string) ([]snippet, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return slices.Clone(defaultSnippets), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snippets []snippet
+	if err := yaml.Unmarshal(data, &snippets); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+
+	return snippets, nil
+}
+
+// saveSnippet adds or replaces a snippet in the snippets file.
+func saveSnippet(path string, s snippet) error {
+	snippets, err := loadSnippets(path)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for n := range snippets {
+		if snippets[n].Name == s.Name {
+			snippets[n] = s
+			replaced = true
+		}
+	}
+	if !replaced {
+		snippets = append(snippets, s)
+	}
+
+	data, err := yaml.Marshal(snippets)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
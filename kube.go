@@ -1,42 +1,34 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
-	"flag"
 	"fmt"
 	"io"
-	"log"
-	"os"
-	"path/filepath"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/yaml"
 )
 
-func InitKubeCtx() *kubernetes.Clientset {
-	kubeconfig := flag.String("kubeconfig", filepath.Join(homeDir(), ".kube", "config"), "absolute path to the kubeconfig file")
-	flag.Parse()
-
-	// Build the config
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+func InitKubeCtx() *kubeConnection {
+	conn, err := connect(*kubeconfigPath, *kubeContextName)
 	if err != nil {
 		panic(err.Error())
 	}
 
-	// Create the clientset
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		panic(err.Error())
-	}
-
-	return clientset
+	return conn
 }
 
 func GetNamespace(clientset *kubernetes.Clientset) []string {
@@ -48,8 +40,7 @@ func GetNamespace(clientset *kubernetes.Clientset) []string {
 	// Print namespace names
 	var nsList []string
 	for _, namespace := range namespaces.Items {
-		ns := fmt.Sprintf(namespace.Name)
-		nsList = append(nsList, ns)
+		nsList = append(nsList, namespace.Name)
 	}
 
 	return nsList
@@ -64,31 +55,103 @@ func GetPods(clientset *kubernetes.Clientset, namespace string) []string {
 	// Print namespace names
 	var podList []string
 	for _, pod := range pods.Items {
-		podName := fmt.Sprintf(pod.Name)
-		podList = append(podList, podName)
+		podList = append(podList, qualifiedName(namespace, pod.Namespace, pod.Name))
 	}
 
 	return podList
 }
 
-func GetContainers(clientset *kubernetes.Clientset, namespace string, podName string) []string {
-	var containerNames []string
+// ContainerInfo describes one init, regular or ephemeral container of a pod.
+type ContainerInfo struct {
+	Name     string
+	Type     string
+	Image    string
+	Ready    bool
+	State    string
+	Restarts int32
+	Stdin    bool
+	TTY      bool
+	Usage    string
+}
 
+func GetContainers(clientset *kubernetes.Clientset, namespace string, podName string) ([]ContainerInfo, error) {
 	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
 	if err != nil {
-		errorMessage := fmt.Sprintf("%s", err.Error())
-		containerNames = append(containerNames, errorMessage)
-	} else {
-		for _, container := range pod.Spec.Containers {
-			containerNames = append(containerNames, container.Name)
+		return nil, err
+	}
+
+	var containers []ContainerInfo
+	for _, container := range pod.Spec.InitContainers {
+		containers = append(containers, containerInfo("init", container.Name, container.Image, container.Stdin, container.TTY, pod.Status.InitContainerStatuses))
+	}
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, containerInfo("main", container.Name, container.Image, container.Stdin, container.TTY, pod.Status.ContainerStatuses))
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		containers = append(containers, containerInfo("debug", container.Name, container.Image, container.Stdin, container.TTY, pod.Status.EphemeralContainerStatuses))
+	}
+
+	return containers, nil
+}
+
+func containerInfo(containerType string, name string, image string, stdin bool, tty bool, statuses []corev1.ContainerStatus) ContainerInfo {
+	info := ContainerInfo{Name: name, Type: containerType, Image: image, State: "Pending", Stdin: stdin, TTY: tty}
+
+	for _, status := range statuses {
+		if status.Name != name {
+			continue
 		}
+
+		info.Ready = status.Ready
+		info.Restarts = status.RestartCount
+		switch {
+		case status.State.Running != nil:
+			info.State = "Running"
+		case status.State.Waiting != nil:
+			info.State = "Waiting: " + status.State.Waiting.Reason
+		case status.State.Terminated != nil:
+			info.State = fmt.Sprintf("Terminated: %s (exit %d)", status.State.Terminated.Reason, status.State.Terminated.ExitCode)
+		}
+	}
+
+	return info
+}
+
+// GetLogs returns the buffered log lines of a container. Containers that have
+// not started yet have no log, which the API reports as an error.
+func GetLogs(clientset *kubernetes.Clientset, namespace string, podName string, containerName string, timestamps bool) ([]string, error) {
+	buffer, err := ReadLogs(clientset, namespace, podName, containerName, timestamps)
+	if err != nil {
+		return nil, err
+	}
+
+	logLines := buffer.snapshot()
+	if buffer.dropped > 0 {
+		logLines = append([]string{buffer.droppedNotice()}, logLines...)
 	}
 
-	return containerNames
+	return logLines, nil
 }
 
-func GetLogs(clientset *kubernetes.Clientset, namespace string, podName string, containerName string) []string {
-	podLogOpts := &corev1.PodLogOptions{}
+// FetchLogs returns the log of a container as one string, reporting failures instead of panicking.
+func FetchLogs(clientset *kubernetes.Clientset, namespace string, podName string, containerName string) (string, error) {
+	buffer, err := ReadLogs(clientset, namespace, podName, containerName, false)
+	if err != nil {
+		return "", err
+	}
+
+	logLines := buffer.snapshot()
+	if buffer.dropped > 0 {
+		logLines = append([]string{buffer.droppedNotice()}, logLines...)
+	}
+
+	return strings.Join(logLines, "\n"), nil
+}
+
+// ReadLogs streams a container log line by line into a bounded buffer, so only
+// the newest lines of huge logs are held in memory.
+func ReadLogs(clientset *kubernetes.Clientset, namespace string, podName string, containerName string, timestamps bool) (*logBuffer, error) {
+	podLogOpts := &corev1.PodLogOptions{Timestamps: timestamps}
 	if containerName != "" {
 		podLogOpts.Container = containerName
 	}
@@ -96,134 +159,188 @@ func GetLogs(clientset *kubernetes.Clientset, namespace string, podName string,
 	req := clientset.CoreV1().Pods(namespace).GetLogs(podName, podLogOpts)
 	podLogs, err := req.Stream(context.TODO())
 	if err != nil {
-		panic(err.Error())
+		return nil, err
 	}
-
-	defer func(podLogs io.ReadCloser) {
-		err := podLogs.Close()
+	defer podLogs.Close()
+
+	buffer := newLogBuffer(*logMaxLines, *logMaxBytes)
+	reader := bufio.NewReader(podLogs)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			buffer.push(strings.TrimSuffix(line, "\n"))
+		}
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			panic(err.Error())
+			return nil, err
 		}
-	}(podLogs)
+	}
+
+	return buffer, nil
+}
 
-	logs, err := io.ReadAll(podLogs)
+// DefaultContainer returns the container kubectl would pick for a pod: the one named by the
+// kubectl.kubernetes.io/default-container annotation, otherwise the first one in the spec.
+func DefaultContainer(clientset *kubernetes.Clientset, namespace string, podName string) (string, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
 	if err != nil {
-		panic(err.Error())
+		return "", err
 	}
 
-	logLines := strings.Split(string(logs), "\n")
+	if name, ok := pod.Annotations["kubectl.kubernetes.io/default-container"]; ok {
+		return name, nil
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return "", fmt.Errorf("pod %q has no containers", podName)
+	}
 
-	return logLines
+	return pod.Spec.Containers[0].Name, nil
 }
 
-// homeDir returns the home directory for the executing user.
-func homeDir() string {
-	dirname, err := os.UserHomeDir()
+// RestartPod deletes a pod so that its controller recreates it. Pods without a
+// controller are refused, since deleting them would not bring them back.
+func RestartPod(clientset *kubernetes.Clientset, namespace string, podName string) error {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	return dirname
-}
+	if metav1.GetControllerOf(pod) == nil {
+		return fmt.Errorf("pod %q is not managed by a controller", podName)
+	}
 
-//
-// Client Go Examples Code
-//
+	return clientset.CoreV1().Pods(namespace).Delete(context.TODO(), podName, metav1.DeleteOptions{})
+}
 
-const debug = false
+// DeleteResource deletes a single object using the given delete options.
+// Namespaces and pods are deleted directly, any other kind the API server
+// serves, such as "deployment" or "service", is found through discovery.
+func DeleteResource(conn *kubeConnection, kind string, namespace string, name string, opts metav1.DeleteOptions) error {
+	switch kind {
+	case "namespace":
+		return conn.clientset.CoreV1().Namespaces().Delete(context.TODO(), name, opts)
+	case "pod":
+		return conn.clientset.CoreV1().Pods(namespace).Delete(context.TODO(), name, opts)
+	}
 
-// GetClientConfig first tries to get a config object which uses the service account kubernetes gives to pods,
-// if it is called from a process running in a kubernetes environment.
-// Otherwise, it tries to build config from a default kubeconfig filepath if it fails, it fallback to the default config.
-// Once it get the config, it returns the same.
-func GetClientConfig() (*rest.Config, error) {
-	config, err := rest.InClusterConfig()
+	resource, err := dynamicResource(conn, kind, namespace)
 	if err != nil {
-		if debug {
-			fmt.Printf("Unable to create config. Error: %+v\n", err)
-		}
-		err1 := err
-		kubeconfig := filepath.Join(os.Getenv("HOME"), ".kube", "config")
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			err = fmt.Errorf("InClusterConfig as well as BuildConfigFromFlags Failed. Error in InClusterConfig: %+v\nError in BuildConfigFromFlags: %+v", err1, err)
-			return nil, err
-		}
+		return err
 	}
 
-	return config, nil
+	return resource.Delete(context.TODO(), name, opts)
 }
 
-// GetClientsetFromConfig takes REST config and Create a clientset based on that and return that clientset
-func GetClientsetFromConfig(config *rest.Config) (*kubernetes.Clientset, error) {
-	clientset, err := kubernetes.NewForConfig(config)
+// GetManifest returns the YAML manifest of an object of any kind the way
+// `kubectl get -o yaml` shows it, without managed fields.
+func GetManifest(conn *kubeConnection, kind string, namespace string, name string) (string, error) {
+	resource, err := dynamicResource(conn, kind, namespace)
 	if err != nil {
-		err = fmt.Errorf("failed creating clientset. Error: %+v", err)
-		return nil, err
+		return "", err
 	}
 
-	return clientset, nil
+	obj, err := resource.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	obj.SetManagedFields(nil)
+	manifest, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return string(manifest), nil
 }
 
-// GetClientset first tries to get a config object which uses the service account kubernetes gives to pods,
-// if it is called from a process running in a kubernetes environment.
-// Otherwise, it tries to build config from a default kubeconfig filepath if it fails, it fallback to the default config.
-// Once it get the config, it creates a new Clientset for the given config and returns the clientset.
-func GetClientset() (*kubernetes.Clientset, error) {
-	config, err := GetClientConfig()
+// dynamicResource looks kind up through discovery, by its singular or plural
+// resource name, and returns a client for it scoped to namespace when the
+// kind is namespaced.
+func dynamicResource(conn *kubeConnection, kind string, namespace string) (dynamic.ResourceInterface, error) {
+	// A single broken API group should not hide the kinds of the others
+	groupResources, err := restmapper.GetAPIGroupResources(conn.clientset.Discovery())
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	gvk, err := mapper.KindFor(schema.GroupVersionResource{Resource: strings.ToLower(kind)})
+	if err != nil {
+		return nil, fmt.Errorf("unknown kind %q: %w", kind, err)
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(conn.config)
 	if err != nil {
 		return nil, err
 	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return client.Resource(mapping.Resource).Namespace(namespace), nil
+	}
 
-	return GetClientsetFromConfig(config)
+	return client.Resource(mapping.Resource), nil
 }
 
-// GetRESTClient first tries to get a config object which uses the service account kubernetes gives to pods,
-// if it is called from a process running in a kubernetes environment.
-// Otherwise, it tries to build config from a default kubeconfig filepath if it fails, it fallback to the default config.
-// Once it get the config, it
-func GetRESTClient() (*rest.RESTClient, error) {
-	config, err := GetClientConfig()
+//
+// Client Go Examples Code
+//
+
+const debug = false
+
+// GetClientsetFromConfig takes REST config and Create a clientset based on that and return that clientset
+func GetClientsetFromConfig(config *rest.Config) (*kubernetes.Clientset, error) {
+	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return &rest.RESTClient{}, err
+		err = fmt.Errorf("failed creating clientset. Error: %+v", err)
+		return nil, err
 	}
 
-	return rest.RESTClientFor(config)
+	return clientset, nil
 }
 
 // ExecToPodThroughAPI uninterractively exec to the pod with the command specified.
+// :param context.Context ctx: cancels the command or bounds how long it runs.
+// :param *kubeConnection conn: connection of the active context.
 // :param string command: list of the str which specify the command.
 // :param string pod_name: Pod name
 // :param string namespace: namespace of the Pod.
 // :param io.Reader stdin: Standerd Input if necessary, otherwise `nil`
-// :return: string: Output of the command. (STDOUT)
+// :return: string: Output of the command, also on failure. (STDOUT)
 //
 //	string: Errors. (STDERR)
 //	 error: If any error has occurred otherwise `nil`
-func ExecToPodThroughAPI(command, containerName, podName, namespace string, stdin io.Reader) (string, string, error) {
-	config, err := GetClientConfig()
+func ExecToPodThroughAPI(ctx context.Context, conn *kubeConnection, command, containerName, podName, namespace string, stdin io.Reader) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := StreamExecToPod(ctx, conn, strings.Fields(command), containerName, podName, namespace, stdin, &stdout, &stderr)
 	if err != nil {
-		return "", "", err
+		// Keep whatever was printed before a non-zero exit
+		return stdout.String(), stderr.String(), fmt.Errorf("error in Stream: %w", err)
 	}
 
-	clientset, err := GetClientsetFromConfig(config)
-	if err != nil {
-		return "", "", err
-	}
+	return stdout.String(), stderr.String(), nil
+}
 
-	req := clientset.CoreV1().RESTClient().Post().
+// StreamExecToPod runs command in the container without a TTY, streaming its
+// stdin and output instead of buffering them. Cancelling ctx aborts the command.
+func StreamExecToPod(ctx context.Context, conn *kubeConnection, command []string, containerName, podName, namespace string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	req := conn.clientset.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(podName).
 		Namespace(namespace).
 		SubResource("exec")
 	scheme := runtime.NewScheme()
 	if err := corev1.AddToScheme(scheme); err != nil {
-		return "", "", fmt.Errorf("error adding to scheme: %v", err)
+		return fmt.Errorf("error adding to scheme: %v", err)
 	}
 
 	parameterCodec := runtime.NewParameterCodec(scheme)
 	req.VersionedParams(&corev1.PodExecOptions{
-		Command:   strings.Fields(command),
+		Command:   command,
 		Container: containerName,
 		Stdin:     stdin != nil,
 		Stdout:    true,
@@ -235,21 +352,15 @@ func ExecToPodThroughAPI(command, containerName, podName, namespace string, stdi
 		fmt.Println("Request URL:", req.URL().String())
 	}
 
-	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	exec, err := newExecutor(conn.config, req.URL())
 	if err != nil {
-		return "", "", fmt.Errorf("error while creating Executor: %v", err)
+		return fmt.Errorf("error while creating Executor: %v", err)
 	}
 
-	var stdout, stderr bytes.Buffer
-	err = exec.Stream(remotecommand.StreamOptions{
+	return exec.StreamWithContext(ctx, remotecommand.StreamOptions{
 		Stdin:  stdin,
-		Stdout: &stdout,
-		Stderr: &stderr,
+		Stdout: stdout,
+		Stderr: stderr,
 		Tty:    false,
 	})
-	if err != nil {
-		return "", "", fmt.Errorf("error in Stream: %v", err)
-	}
-
-	return stdout.String(), stderr.String(), nil
 }
@@ -10,6 +10,62 @@ type listKeyMap struct {
 	selection        key.Binding
 	back             key.Binding
 	exec             key.Binding
+	mark             key.Binding
+	delete           key.Binding
+	restart          key.Binding
+	bulkLogs         key.Binding
+	aggregateLogs    key.Binding
+	search           key.Binding
+	nextMatch        key.Binding
+	prevMatch        key.Binding
+	grep             key.Binding
+	moreContext      key.Binding
+	lessContext      key.Binding
+	pretty           key.Binding
+	expand           key.Binding
+	fieldFilter      key.Binding
+	save             key.Binding
+	wrap             key.Binding
+	scrollLeft       key.Binding
+	scrollRight      key.Binding
+	pageDown         key.Binding
+	pageUp           key.Binding
+	halfPageDown     key.Binding
+	halfPageUp       key.Binding
+	timestamps       key.Binding
+	jumpToTime       key.Binding
+	debug            key.Binding
+	attach           key.Binding
+	fanOut           key.Binding
+	nodes            key.Binding
+	blockers         key.Binding
+	allNamespaces    key.Binding
+	ownerTree        key.Binding
+	services         key.Binding
+	labelSelector    key.Binding
+	toggleNode       key.Binding
+	sortUsage        key.Binding
+	usageChart       key.Binding
+	cancelExec       key.Binding
+	groupResults     key.Binding
+	historyPrev      key.Binding
+	historyNext      key.Binding
+	historySearch    key.Binding
+	snippets         key.Binding
+	saveSnippet      key.Binding
+	files            key.Binding
+	download         key.Binding
+	upload           key.Binding
+	yank             key.Binding
+	yankRange        key.Binding
+	yankManifest     key.Binding
+	portForward      key.Binding
+	forwards         key.Binding
+	stopForward      key.Binding
+	confirm          key.Binding
+	cancel           key.Binding
+	propagation      key.Binding
+	force            key.Binding
 }
 
 func newListKeyMap() *listKeyMap {
@@ -42,5 +98,229 @@ func newListKeyMap() *listKeyMap {
 			key.WithKeys("e"),
 			key.WithHelp("e", "start shell session in container"),
 		),
+		mark: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "mark entry"),
+		),
+		delete: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "delete entry"),
+		),
+		restart: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "restart pod"),
+		),
+		bulkLogs: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "view pod logs"),
+		),
+		aggregateLogs: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "aggregate logs by selector"),
+		),
+		search: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "regex search"),
+		),
+		nextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		prevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		grep: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("ctrl+g", "toggle grep mode"),
+		),
+		moreContext: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "more context"),
+		),
+		lessContext: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "less context"),
+		),
+		pretty: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "toggle raw log lines"),
+		),
+		expand: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "expand log fields"),
+		),
+		fieldFilter: key.NewBinding(
+			key.WithKeys("ctrl+w"),
+			key.WithHelp("ctrl+w", "filter by field"),
+		),
+		save: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save to file"),
+		),
+		wrap: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "toggle wrap"),
+		),
+		scrollLeft: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "scroll left"),
+		),
+		scrollRight: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "scroll right"),
+		),
+		pageDown: key.NewBinding(
+			key.WithKeys("pgdown", "f"),
+			key.WithHelp("f/pgdn", "page down"),
+		),
+		pageUp: key.NewBinding(
+			key.WithKeys("pgup", "b"),
+			key.WithHelp("b/pgup", "page up"),
+		),
+		halfPageDown: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "half page down"),
+		),
+		halfPageUp: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "half page up"),
+		),
+		timestamps: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "toggle timestamps"),
+		),
+		jumpToTime: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "jump to time"),
+		),
+		debug: key.NewBinding(
+			key.WithKeys("ctrl+b"),
+			key.WithHelp("ctrl+b", "debug with ephemeral container"),
+		),
+		labelSelector: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "label selector"),
+		),
+		services: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "forward a service"),
+		),
+		ownerTree: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "owner tree"),
+		),
+		toggleNode: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "expand/collapse"),
+		),
+		allNamespaces: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "all namespaces"),
+		),
+		blockers: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "deletion blockers"),
+		),
+		nodes: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "nodes"),
+		),
+		sortUsage: key.NewBinding(
+			key.WithKeys("%"),
+			key.WithHelp("%", "sort by usage"),
+		),
+		usageChart: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "usage chart"),
+		),
+		fanOut: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "exec in matching pods"),
+		),
+		cancelExec: key.NewBinding(
+			key.WithKeys("ctrl+k"),
+			key.WithHelp("ctrl+k", "cancel command"),
+		),
+		groupResults: key.NewBinding(
+			key.WithKeys("="),
+			key.WithHelp("=", "group identical outputs"),
+		),
+		historyPrev: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous command"),
+		),
+		historyNext: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next command"),
+		),
+		historySearch: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "search history"),
+		),
+		snippets: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "snippets"),
+		),
+		saveSnippet: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "save as snippet"),
+		),
+		attach: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "attach"),
+		),
+		files: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "browse files"),
+		),
+		download: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "download"),
+		),
+		upload: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "upload"),
+		),
+		yank: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy to clipboard"),
+		),
+		yankRange: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "start copy range"),
+		),
+		yankManifest: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "copy manifest"),
+		),
+		portForward: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "port-forward pod"),
+		),
+		forwards: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "port forwards"),
+		),
+		stopForward: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "stop forward"),
+		),
+		confirm: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "confirm"),
+		),
+		cancel: key.NewBinding(
+			key.WithKeys("n", "esc"),
+			key.WithHelp("n/esc", "cancel"),
+		),
+		propagation: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "cycle propagation policy"),
+		),
+		force: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "toggle force delete"),
+		),
 	}
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// logViewportChrome is the number of rows taken by the title, the blank lines
+// around the body, the status bar and the help line.
+const logViewportChrome = 5
+
+// horizontalScrollStep is how many columns one left/right key press scrolls.
+const horizontalScrollStep = 8
+
+// logViewport renders the Logs view. The list still owns the lines, the cursor
+// and the filter; the viewport decides which rows are on screen, soft wraps or
+// scrolls them horizontally, and only formats the lines that are visible.
+type logViewport struct {
+	top     int
+	xOffset int
+	wrap    bool
+}
+
+func (v logViewport) bodyHeight(l list.Model) int {
+	return max(l.Height()-logViewportChrome, 1)
+}
+
+// textWidth leaves room for the four columns of cursor and padding.
+func (v logViewport) textWidth(l list.Model) int {
+	return max(l.Width()-4, 1)
+}
+
+// rows returns how many screen rows a formatted line takes.
+func (v logViewport) rows(line string, width int) int {
+	if !v.wrap {
+		return 1
+	}
+
+	return max(1, (ansi.StringWidth(line)+width-1)/width)
+}
+
+// follow scrolls just enough to keep the cursor on screen.
+func (v *logViewport) follow(l list.Model, d itemDelegate) {
+	items := l.VisibleItems()
+	cursor := l.Index()
+	height, width := v.bodyHeight(l), v.textWidth(l)
+
+	v.top = max(min(v.top, cursor, len(items)-1), 0)
+	if len(items) == 0 {
+		return
+	}
+	if !v.wrap {
+		v.top = max(v.top, cursor-height+1)
+		return
+	}
+
+	// Walk back from the cursor, so at most a screen of lines is formatted
+	first, used := cursor, v.rows(d.line(items[cursor]), width)
+	for n := cursor - 1; n >= v.top; n-- {
+		used += v.rows(d.line(items[n]), width)
+		if used > height {
+			break
+		}
+		first = n
+	}
+	v.top = first
+}
+
+// scroll moves the cursor and the first visible line by delta lines.
+func (v *logViewport) scroll(l *list.Model, delta int) {
+	last := len(l.VisibleItems()) - 1
+	if last < 0 {
+		return
+	}
+
+	l.Select(max(min(l.Index()+delta, last), 0))
+	v.top = max(min(v.top+delta, last), 0)
+}
+
+func (v logViewport) View(l list.Model, d itemDelegate) string {
+	var b strings.Builder
+
+	if l.SettingFilter() {
+		b.WriteString(l.FilterInput.View())
+	} else {
+		b.WriteString(l.Styles.Title.Render(l.Title))
+	}
+	b.WriteString("\n\n")
+
+	items := l.VisibleItems()
+	height, width := v.bodyHeight(l), v.textWidth(l)
+	rows := 0
+	for n := v.top; n < len(items) && rows < height; n++ {
+		line := d.line(items[n])
+
+		var segments []string
+		if v.wrap {
+			segments = strings.Split(ansi.Hardwrap(line, width, true), "\n")
+		} else {
+			segments = []string{ansi.Cut(line, v.xOffset, v.xOffset+width)}
+		}
+
+		for k, segment := range segments {
+			if rows == height {
+				break
+			}
+
+			switch {
+			case n == l.Index() && k == 0:
+				b.WriteString(selectedItemStyle.Render("> " + segment))
+			case n == l.Index():
+				b.WriteString(selectedItemStyle.Render("  " + segment))
+			default:
+				b.WriteString(itemStyle.Render(segment))
+			}
+			b.WriteString("\n")
+			rows++
+		}
+	}
+	for ; rows < height; rows++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(l.Styles.StatusBar.Render(v.status(l)))
+	b.WriteString("\n")
+	b.WriteString(l.Help.View(l))
+
+	return b.String()
+}
+
+func (v logViewport) status(l list.Model) string {
+	status := fmt.Sprintf("line %d/%d", l.Index()+1, len(l.VisibleItems()))
+	if l.IsFiltered() {
+		status = fmt.Sprintf("“%s” %s", l.FilterValue(), status)
+	}
+
+	if v.wrap {
+		status += " • wrap on"
+	} else {
+		status += fmt.Sprintf(" • wrap off • col %d", v.xOffset+1)
+	}
+
+	return status
+}
+
+// splitTimestamp splits the RFC3339 timestamp the API server prefixes log lines
+// with when timestamps are requested.
+func splitTimestamp(line string) (time.Time, string, bool) {
+	stamp, text, found := strings.Cut(line, " ")
+	if !found {
+		return time.Time{}, line, false
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return time.Time{}, line, false
+	}
+
+	return ts, text, true
+}
+
+var jumpTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04", "15:04:05", "15:04"}
+
+// findTimestamp returns the first line logged at or after the given time. A
+// time of day without a date refers to the day of the first timestamped line.
+func findTimestamp(items []list.Item, value string) (int, error) {
+	var target time.Time
+	var err error
+	timeOnly := false
+	for _, layout := range jumpTimeLayouts {
+		target, err = time.Parse(layout, strings.TrimSpace(value))
+		if err == nil {
+			timeOnly = !strings.Contains(layout, "2006")
+			break
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unrecognised time %q", value)
+	}
+
+	for n, listItem := range items {
+		ts, _, ok := splitTimestamp(itemText(listItem))
+		if !ok {
+			continue
+		}
+
+		if timeOnly {
+			y, mo, d := ts.Date()
+			target = time.Date(y, mo, d, target.Hour(), target.Minute(), target.Second(), 0, ts.Location())
+			timeOnly = false
+		}
+		if !ts.Before(target) {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no line logged at or after %s", value)
+}
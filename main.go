@@ -1,8 +1,10 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -10,7 +12,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"k8s.io/client-go/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type item string
@@ -18,6 +20,19 @@ type item string
 func (i item) Title() string       { return string(i) }
 func (i item) FilterValue() string { return string(i) }
 
+// containerItem is a row of the Containers view.
+type containerItem ContainerInfo
+
+func (c containerItem) Title() string {
+	ready := "not ready"
+	if c.Ready {
+		ready = "ready"
+	}
+
+	return fmt.Sprintf("%-5s  %-30s  %-9s  restarts %-3d  %-36s  %-*s  %s", c.Type, c.Name, ready, c.Restarts, c.State, usageWidth, c.Usage, c.Image)
+}
+func (c containerItem) FilterValue() string { return c.Type + " " + c.Name }
+
 type model struct {
 	keys         *listKeyMap
 	delegateKeys *delegateKeyMap
@@ -25,24 +40,80 @@ type model struct {
 	containerHeight int
 	containerWidth  int
 
-	kubeContext  *kubernetes.Clientset
+	conn         *kubeConnection
 	currentView  int
 	selectedItem string
+	marked       map[string]bool
+	columns      map[string]columnRow
 
 	currentNamespace string
 	currentPod       string
 	currentContainer string
 	currentLog       string
+	allNamespaces    bool
 
 	displayList   list.Model
 	namespaceList list.Model
 	podList       list.Model
 	containerList list.Model
+	filesList     list.Model
 	logList       list.Model
 
-	execInput  textinput.Model
-	execError  string
-	execResult string
+	execInput textinput.Model
+	execError string
+	execBytes int
+	execSpool string
+
+	deleteModal deleteModal
+
+	exec               *execSession
+	history            *execHistory
+	snippetsParentView int
+
+	execTargets       []string
+	execContainer     string
+	bulkResults       []bulkResult
+	resultsAction     string
+	resultsTitle      string
+	resultsParentView int
+	resultsGrouped    bool
+
+	forwards           *portForwardManager
+	prompt             promptModal
+	forwardsParentView int
+	forwardsTick       int
+
+	search logSearch
+	detail string
+
+	transferStatus string
+
+	logViewport   logViewport
+	logTimestamps bool
+
+	rangeActive bool
+	rangeStart  int
+	rangeView   int
+
+	filesDir        string
+	pagerTitle      string
+	pagerParentView int
+
+	podUsage     map[string]podUsage
+	nodeUsage    map[string]nodeUsage
+	usageSort    int
+	usageErr     string
+	usageHistory *usageHistory
+	chartPod     string
+
+	namespacesErr string
+
+	tree          []*treeNode
+	treeCollapsed map[string]bool
+
+	aggregator        *logAggregator
+	aggregateSelector string
+	aggregateDropped  int
 }
 
 func newModel() model {
@@ -52,16 +123,17 @@ func newModel() model {
 	)
 
 	// Setup Kube Context
-	ctx := InitKubeCtx()
+	conn := InitKubeCtx()
+	marked := map[string]bool{}
 
 	namespaceItemList := []list.Item{}
-	namespaceList := GetNamespace(ctx)
+	namespaceList := GetNamespace(conn.clientset)
 	for _, listData := range namespaceList {
 		namespaceItemList = append(namespaceItemList, item(listData))
 	}
 
 	// Setup list
-	currentList := list.New(namespaceItemList, itemDelegate{}, 0, 0)
+	currentList := list.New(namespaceItemList, itemDelegate{marked: marked, raw: true}, 0, 0)
 	currentList.Title = "[KUCO] Namespaces"
 	currentList.Styles.Title = titleStyle
 	currentList.AdditionalFullHelpKeys = func() []key.Binding {
@@ -77,6 +149,8 @@ func newModel() model {
 	currentList.AdditionalShortHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			listKeys.selection,
+			listKeys.mark,
+			listKeys.delete,
 		}
 	}
 
@@ -91,7 +165,13 @@ func newModel() model {
 		displayList:      currentList,
 		keys:             listKeys,
 		delegateKeys:     delegateKeys,
-		kubeContext:      ctx,
+		conn:             conn,
+		marked:           marked,
+		columns:          map[string]columnRow{},
+		usageHistory:     newUsageHistory(),
+		treeCollapsed:    map[string]bool{},
+		forwards:         &portForwardManager{},
+		history:          loadExecHistory(*execHistoryPath),
 		currentView:      0, // Namespace View
 		currentContainer: "",
 		currentPod:       "",
@@ -99,15 +179,27 @@ func newModel() model {
 		currentNamespace: "",
 		execInput:        ti,
 		execError:        "",
-		execResult:       "",
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.refreshColumns(), metricsTick(), namespacesTick())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+
+	// Keep the cursor of the pager on screen whatever moved it
+	next, ok := updated.(model)
+	if ok && next.inPager() {
+		next.logViewport.follow(next.displayList, next.pagerDelegate())
+		return next, cmd
+	}
+
+	return updated, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
@@ -120,13 +212,224 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.displayList.SetSize(width, height-9)
 		m.containerWidth = width
 		m.containerHeight = height
+	case bulkResultMsg:
+		// The exec was abandoned by going back
+		if msg.action == "Exec" && msg.session != m.exec {
+			return m, nil
+		}
+
+		m.stopExec()
+		m.execTargets = nil
+		m.execContainer = ""
+		m.bulkResults = msg.results
+		m.resultsAction = msg.action
+		m.resultsGrouped = false
+		m.resultsTitle = fmt.Sprintf("[KUCO] %s Results", msg.action)
+		m.currentLog = ""
+		m.currentView = 6 // switch to results view
+
+		resultItemList := m.resultItems()
+		if msg.action == "Logs" {
+			m.bulkResults = nil
+			resultItemList = bulkLogItems(msg.results)
+		}
+		m.displayList = updateDisplayList(m, resultItemList)
+
+		return m, nil
+
+	case portForwardStartedMsg:
+		if msg.err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Port-forward failed: " + msg.err.Error()))
+		}
+
+		m.forwards.add(msg.forward)
+		return m, m.displayList.NewStatusMessage(statusMessageStyle("Forwarding " + msg.forward.String()))
+
+	case logLinesMsg:
+		if m.aggregator == nil || m.currentView != 8 {
+			return m, nil
+		}
+
+		// An empty list has no cursor yet, the first lines start the tail
+		visible := len(m.displayList.VisibleItems())
+		following := visible == 0 || m.displayList.Index() == visible-1
+		itemList, dropped := mergeLogLines(m.displayList.Items(), msg, *logMaxLines, *logMaxBytes)
+		cmd := setItemsKeepingSelection(&m.displayList, itemList)
+		if following {
+			// Stay on the newest line while tailing
+			m.displayList.Select(len(m.displayList.VisibleItems()) - 1)
+		}
+		if dropped > 0 {
+			m.aggregateDropped += dropped
+			m.displayList.Title = fmt.Sprintf("[KUCO] Aggregated Logs (%s, %d older lines dropped)", m.aggregateSelector, m.aggregateDropped)
+		}
+
+		return m, tea.Batch(cmd, m.aggregator.wait())
+
+	case transferMsg:
+		if !msg.done {
+			m.transferStatus = msg.String()
+			return m, waitForTransfer(msg.progress)
+		}
+
+		// The outcome goes to the status bar, which clears itself
+		m.transferStatus = ""
+		cmd := m.displayList.NewStatusMessage(statusMessageStyle(msg.String()))
+		if msg.err == nil && m.currentView == 9 {
+			// Show uploaded files straight away
+			return m, tea.Batch(cmd, listFilesCmd(m.conn, m.currentNamespace, m.currentPod, m.currentContainer, m.filesDir))
+		}
+		return m, cmd
+
+	case filesMsg:
+		if m.currentView != 9 || msg.pod != m.currentPod || msg.container != m.currentContainer {
+			return m, nil
+		}
+
+		if msg.dir == m.filesDir {
+			// A refresh keeps the cursor where it was
+			return m, m.displayList.SetItems(fileItems(msg.entries, msg.err))
+		}
+		if msg.err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle(msg.err.Error()))
+		}
+
+		m.filesDir = msg.dir
+		m.displayList = updateDisplayList(m, fileItems(msg.entries, nil))
+		return m, nil
+
+	case previewMsg:
+		if m.currentView != 9 || msg.pod != m.currentPod || msg.container != m.currentContainer {
+			return m, nil
+		}
+
+		if msg.err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle(msg.err.Error()))
+		}
+		return m.openPreview(msg), nil
+
+	case clipboardMsg:
+		if msg.err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Copy failed: " + msg.err.Error()))
+		}
+
+		return m, m.displayList.NewStatusMessage(statusMessageStyle(msg.done))
+
+	case metricsTickMsg:
+		// Namespace details are listed cluster-wide and refresh on their own tick
+		var cmd tea.Cmd
+		if m.currentView != 0 {
+			cmd = m.refreshColumns()
+		}
+		return m, tea.Batch(cmd, metricsTick())
+
+	case namespacesTickMsg:
+		var cmd tea.Cmd
+		if m.currentView == 0 {
+			cmd = m.refreshColumns()
+		}
+		return m, tea.Batch(cmd, namespacesTick())
+
+	case namespacesMsg:
+		if m.currentView != 0 {
+			return m, nil
+		}
+
+		return m, m.applyNamespaces(msg)
+
+	case blockersMsg:
+		if msg.err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Checking " + msg.namespace + " failed: " + msg.err.Error()))
+		}
+		if m.currentView != 0 {
+			return m, nil
+		}
+
+		return m.openPager("[KUCO] Blockers of namespace "+msg.namespace, msg.lines, 0), nil
+
+	case usageMsg:
+		if msg.view != m.currentView || (msg.view != 12 && msg.namespace != m.currentNamespace) {
+			return m, nil
+		}
+
+		return m, m.applyUsage(msg)
+
+	case execOutputMsg:
+		if msg.session != m.exec {
+			return m, nil
+		}
+
+		return m, tea.Batch(m.appendExecOutput(msg.lines), m.exec.wait())
+
+	case execDoneMsg:
+		if msg.session != m.exec {
+			return m, nil
+		}
+
+		return m, m.finishExec(msg.err)
+
+	case debugReadyMsg:
+		if msg.err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Debug container failed: " + msg.err.Error()))
+		}
+
+		banner := fmt.Sprintf("Attached to %s in pod %s. Exit the shell or press %s to return to kuco.\r", msg.container, msg.pod, detachKeysHelp())
+		session := newAttachSession(m.conn, msg.namespace, msg.pod, msg.container, true, true, banner)
+		return m, tea.Exec(session, func(err error) tea.Msg {
+			return sessionEndedMsg{container: msg.container, err: err}
+		})
+
+	case sessionEndedMsg:
+		status := "Session in " + msg.container + " ended"
+		if msg.err != nil {
+			status += ": " + msg.err.Error()
+		}
+
+		var cmd tea.Cmd
+		if m.currentView == 2 {
+			cmd = m.displayList.SetItems(listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer))
+		}
+		return m, tea.Batch(cmd, m.displayList.NewStatusMessage(statusMessageStyle(status)))
+
+	case portForwardTickMsg:
+		// Only keep refreshing while the forwards are on screen
+		if m.currentView != 7 || msg.generation != m.forwardsTick {
+			return m, nil
+		}
+
+		return m, tea.Batch(m.displayList.SetItems(m.forwards.items()), portForwardTick(m.forwardsTick))
+
 	case tea.KeyMsg:
+		// The delete dialog captures all input while it is open.
+		if m.deleteModal.active {
+			return m.updateDeleteModal(msg)
+		}
+		if m.prompt.active {
+			return m.updatePrompt(msg)
+		}
+		// Any key dismisses the detail popup
+		if m.detail != "" {
+			m.detail = ""
+			return m, nil
+		}
+
 		// Don't match any of the keys below if we're actively filtering.
 		if m.displayList.FilterState() == list.Filtering {
 			break
 		}
 
 		switch {
+		case m.currentView == 4 && m.history.searching && (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace || msg.Type == tea.KeyBackspace):
+			// Typing refines the reverse search, starting over from the newest command
+			query := []rune(m.history.query)
+			if msg.Type == tea.KeyBackspace {
+				query = query[:max(len(query)-1, 0)]
+			} else {
+				query = append(query, msg.Runes...)
+			}
+
+			return m, m.searchHistory(string(query))
+
 		case key.Matches(msg, m.keys.toggleTitleBar):
 			v := !m.displayList.ShowTitle()
 			m.displayList.SetShowTitle(v)
@@ -146,12 +449,82 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.displayList.SetShowHelp(!m.displayList.ShowHelp())
 			return m, nil
 
+		case key.Matches(msg, m.keys.cancelExec) && m.exec != nil && (m.currentView == 4 || m.currentView == 5):
+			m.exec.cancel()
+			return m, nil
+
 		case key.Matches(msg, m.keys.back):
-			if m.currentView > 0 {
+			if m.currentView >= 4 && m.currentView <= 5 {
+				m.stopExec()
+			}
+
+			if m.currentView == 6 {
+				// Return to the list the bulk action was started from
+				m.currentView = m.resultsParentView
+			} else if m.currentView == 7 {
+				m.currentView = m.forwardsParentView
+			} else if m.currentView == 8 {
+				m.aggregator.stop()
+				m.aggregator = nil
+				m.currentView = 1
+			} else if m.currentView == 9 && m.filesDir != "/" {
+				return m, listFilesCmd(m.conn, m.currentNamespace, m.currentPod, m.currentContainer, path.Dir(m.filesDir))
+			} else if m.currentView == 9 {
+				m.currentView = 2
+			} else if m.currentView == 12 {
+				m.currentView = 0
+			} else if m.currentView == 13 {
+				m.currentView = 1
+			} else if m.currentView == 16 {
+				m.currentView = 1
+			} else if m.currentView == 15 {
+				m.currentView = 1
+			} else if m.currentView == 14 {
+				m.tree = nil
+				m.currentView = 1
+			} else if m.currentView == 2 && m.tree != nil {
+				// Return to the tree the pod was opened from
+				m.currentView = 14
+				treeItemList := m.ownerTreeList()
+				m.displayList = updateDisplayList(m, treeItemList)
+				return m, nil
+			} else if m.currentView == 11 {
+				m.currentView = m.snippetsParentView
+				if m.currentView == 4 {
+					// Show the list the exec was started from under the input again
+					parent := 2
+					if len(m.execTargets) > 0 {
+						parent = 1
+					}
+					m.currentView = parent
+					m.displayList = updateDisplayList(m, listToItemList(m.conn.clientset, m.currentNamespace, parent, m.currentPod, m.currentContainer))
+					m.currentView = 4
+					return m, nil
+				}
+			} else if m.currentView == 10 {
+				m.currentView = m.pagerParentView
+				m.currentLog = ""
+				switch m.currentView {
+				case 9:
+					// The listing is kept from before the preview, no need to list again
+					m.displayList = updateDisplayList(m, m.filesList.Items())
+					m.displayList.Select(m.filesList.Index())
+					return m, nil
+				case 6:
+					m.displayList = updateDisplayList(m, m.resultItems())
+					return m, nil
+				}
+			} else if m.currentView >= 4 && len(m.execTargets) > 0 {
+				// Bulk exec was started from the pod list
+				m.currentView = 1
+				m.execTargets = nil
+				m.execContainer = ""
+				m.execInput.Reset()
+			} else if m.currentView > 0 {
 				if m.currentView >= 4 {
 					m.currentView = 3
 					m.execInput.Reset()
-					m.execResult = ""
+					m.execBytes = 0
 					m.execError = ""
 				}
 				m.currentView -= 1
@@ -160,31 +533,455 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch m.currentView {
 			case 0:
 				m.currentView = 0 // switch to pod view
-				namespaceItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				m.allNamespaces = false
+				namespaceItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, namespaceItemList)
 			case 1:
 				m.currentView = 1 // switch to pod view
-				podItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				if m.allNamespaces {
+					m.currentNamespace = metav1.NamespaceAll
+					m.podUsage = nil
+				}
+				podItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, podItemList)
 			case 2:
 				m.currentView = 2 // switch to pod view
-				containerItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				containerItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, containerItemList)
 			case 3:
 				m.currentView = 3 // switch to pod view
-				logItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				logItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, logItemList)
+			case 14:
+				treeItemList := m.ownerTreeList()
+				m.displayList = updateDisplayList(m, treeItemList)
+			case 15:
+				m.displayList = updateDisplayList(m, serviceItemList(m.conn.clientset, m.currentNamespace))
 			}
+			cmds = append(cmds, m.refreshColumns())
 
-		case key.Matches(msg, m.keys.exec):
+		case key.Matches(msg, m.keys.mark) && (m.currentView == 0 || m.currentView == 1):
+			i, ok := m.displayList.SelectedItem().(item)
+			if ok {
+				if m.marked[string(i)] {
+					delete(m.marked, string(i))
+				} else {
+					m.marked[string(i)] = true
+				}
+			}
+			setMarkedStatus(&m.displayList, len(m.marked))
+			m.displayList.CursorDown()
+
+			return m, nil
+
+		case key.Matches(msg, m.keys.delete) && (m.currentView == 0 || m.currentView == 1 || m.currentView == 14):
+			kind, _, _, ok := m.selectedObject()
+			if !ok {
+				return m, nil
+			}
+
+			targets := selectedTargets(m)
+			if m.currentView == 14 {
+				targets = []string{m.displayList.SelectedItem().(treeRow).node.name}
+			}
+			if len(targets) > 0 {
+				m.deleteModal = newDeleteModal(kind, m.currentNamespace, targets)
+			}
+
+			return m, nil
+
+		case key.Matches(msg, m.keys.stopForward) && m.currentView == 7:
+			m.forwards.remove(m.displayList.GlobalIndex())
+			return m, m.displayList.SetItems(m.forwards.items())
+
+		case key.Matches(msg, m.keys.services) && m.currentView == 1:
+			if !m.scopeToSelected() {
+				return m, nil
+			}
+
+			m.currentView = 15 // switch to services view
+			m.displayList = updateDisplayList(m, serviceItemList(m.conn.clientset, m.currentNamespace))
+			return m, nil
+
+		case key.Matches(msg, m.keys.portForward) && m.currentView == 1:
+			i, ok := m.displayList.SelectedItem().(item)
+			if ok {
+				m.prompt = newPromptModal("portforward", string(i), "Port-forward pod/"+string(i)+": LOCAL:REMOTE", "8080:80 or 8080:http")
+			}
+
+			return m, nil
+
+		case key.Matches(msg, m.keys.wrap) && m.inPager():
+			m.logViewport.wrap = !m.logViewport.wrap
+			m.logViewport.xOffset = 0
+			return m, nil
+
+		case key.Matches(msg, m.keys.scrollLeft) && m.inPager():
+			m.logViewport.xOffset = max(m.logViewport.xOffset-horizontalScrollStep, 0)
+			return m, nil
+
+		case key.Matches(msg, m.keys.scrollRight) && m.inPager():
+			if !m.logViewport.wrap {
+				m.logViewport.xOffset += horizontalScrollStep
+			}
+			return m, nil
+
+		case (key.Matches(msg, m.keys.pageDown) || key.Matches(msg, m.keys.pageUp) ||
+			key.Matches(msg, m.keys.halfPageDown) || key.Matches(msg, m.keys.halfPageUp)) && m.inPager():
+			delta := m.logViewport.bodyHeight(m.displayList)
+			if key.Matches(msg, m.keys.halfPageDown) || key.Matches(msg, m.keys.halfPageUp) {
+				delta = max(delta/2, 1)
+			}
+			if key.Matches(msg, m.keys.pageUp) || key.Matches(msg, m.keys.halfPageUp) {
+				delta = -delta
+			}
+			m.logViewport.scroll(&m.displayList, delta)
+
+			return m, nil
+
+		case key.Matches(msg, m.keys.timestamps) && m.currentView == 3:
+			logLines, err := GetLogs(m.conn.clientset, m.currentNamespace, m.currentPod, m.currentContainer, !m.logTimestamps)
+			if err != nil {
+				return m, m.displayList.NewStatusMessage(statusMessageStyle("Reloading logs failed: " + err.Error()))
+			}
+			m.logTimestamps = !m.logTimestamps
+			m.search = logSearch{}
+			m.logViewport.top = 0
+
+			var logItemList []list.Item
+			for _, line := range logLines {
+				logItemList = append(logItemList, item(line))
+			}
+			m.displayList.ResetFilter()
+			m.displayList.SetDelegate(m.logDelegate())
+
+			return m, m.displayList.SetItems(logItemList)
+
+		case key.Matches(msg, m.keys.jumpToTime) && m.currentView == 3:
+			if !m.logTimestamps {
+				return m, m.displayList.NewStatusMessage(statusMessageStyle("Enable timestamps with ctrl+t first"))
+			}
+
+			m.prompt = newPromptModal("jumptime", "", "Jump to time", "2025-01-02T15:04:05Z or 15:04")
+			return m, nil
+
+		case key.Matches(msg, m.keys.debug) && m.currentView == 2:
+			target := selectedName(m.displayList)
+			m.prompt = newPromptModal("debug", target, "Debug pod "+m.currentPod+": IMAGE [--target to share "+target+"'s processes]", defaultDebugImage+" --target")
+			return m, nil
+
+		case key.Matches(msg, m.keys.attach) && m.currentView == 2:
+			c, ok := m.displayList.SelectedItem().(containerItem)
+			if !ok {
+				return m, nil
+			}
+
+			mode := "read-only"
+			if c.Stdin {
+				mode = "input enabled"
+			}
+			if c.TTY {
+				mode += ", TTY"
+			}
+			banner := fmt.Sprintf("Attached to %s in pod %s (%s). Press %s to detach and return to kuco.\r", c.Name, m.currentPod, mode, detachKeysHelp())
+			session := newAttachSession(m.conn, m.currentNamespace, m.currentPod, c.Name, c.Stdin, c.TTY, banner)
+
+			return m, tea.Exec(session, func(err error) tea.Msg {
+				return sessionEndedMsg{container: c.Name, err: err}
+			})
+
+		case key.Matches(msg, m.keys.allNamespaces) && m.currentView == 0:
+			m.currentNamespace = metav1.NamespaceAll
+			m.allNamespaces = true
+			m.namespaceList = m.displayList
+			m.podUsage = nil
+			m.currentView = 1 // switch to pod view
+			podItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+			m.displayList = updateDisplayList(m, podItemList)
+			return m, m.refreshColumns()
+
+		case key.Matches(msg, m.keys.blockers) && m.currentView == 0:
+			namespace := selectedName(m.displayList)
+			if namespace == "" {
+				return m, nil
+			}
+
+			conn := m.conn
+			cmd := m.displayList.NewStatusMessage(statusMessageStyle("Looking for what blocks " + namespace + "..."))
+			return m, tea.Batch(cmd, func() tea.Msg {
+				lines, err := NamespaceBlockers(conn, namespace)
+				return blockersMsg{namespace: namespace, lines: lines, err: err}
+			})
+
+		case key.Matches(msg, m.keys.nodes) && m.currentView == 0:
+			m.currentView = 12 // switch to nodes view
+			m.displayList = updateDisplayList(m, nodeItemList(m.conn.clientset))
+			return m, m.refreshColumns()
+
+		case key.Matches(msg, m.keys.ownerTree) && m.currentView == 1:
+			if !m.scopeToSelected() {
+				return m, nil
+			}
+
+			// Paths repeat across namespaces, so collapsed rows are not kept
+			clear(m.treeCollapsed)
+			m.currentView = 14 // switch to tree view
+			treeItemList := m.ownerTreeList()
+			m.displayList = updateDisplayList(m, treeItemList)
+			return m, nil
+
+		case key.Matches(msg, m.keys.toggleNode) && m.currentView == 14:
+			m.toggleTreeRow()
+			return m, nil
+
+		case key.Matches(msg, m.keys.usageChart) && m.currentView == 1:
+			m.chartPod = selectedName(m.displayList)
+			if m.chartPod == "" {
+				return m, nil
+			}
+
+			m.currentView = 13 // switch to usage chart
+			return m, m.refreshColumns()
+
+		case key.Matches(msg, m.keys.sortUsage) && (m.currentView == 1 || m.currentView == 12):
+			m.usageSort = (m.usageSort + 1) % len(sortNames)
+			m.displayList.Title = m.usageTitle(m.podsTitle())
+			if m.currentView == 12 {
+				m.displayList.Title = m.usageTitle("[KUCO] Nodes")
+			}
+			return m, m.sortByUsage()
+
+		case key.Matches(msg, m.keys.files) && m.currentView == 2:
+			m.currentContainer = selectedName(m.displayList)
+			m.containerList = m.displayList
+			m.filesDir = "/"
+			m.currentLog = ""
+			m.currentView = 9 // switch to files view
+			m.displayList = updateDisplayList(m, []list.Item{item("Listing " + m.filesDir + "...")})
+
+			return m, listFilesCmd(m.conn, m.currentNamespace, m.currentPod, m.currentContainer, m.filesDir)
+
+		case key.Matches(msg, m.keys.download) && m.currentView == 9:
+			entry, remotePath, ok := m.selectedPath()
+			if !ok || entry.name == ".." {
+				return m, nil
+			}
+
+			m.prompt = newPromptModal("download", remotePath, "Download "+m.currentContainer+":"+remotePath+" to (an existing directory receives it inside)", "./"+entry.name)
+			return m, nil
+
+		case key.Matches(msg, m.keys.upload) && m.currentView == 9:
+			m.prompt = newPromptModal("upload", m.filesDir, "Upload a local file or directory into "+m.currentContainer+":"+m.filesDir, "~/config.yaml")
+			return m, nil
+
+		case key.Matches(msg, m.keys.historyPrev) && m.currentView == 4:
+			if command, ok := m.history.previous(); ok {
+				m.execInput.SetValue(command)
+				m.execInput.CursorEnd()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.historyNext) && m.currentView == 4:
+			if command, ok := m.history.next(); ok {
+				m.execInput.SetValue(command)
+				m.execInput.CursorEnd()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.historySearch) && m.currentView == 4:
+			query := m.history.query
+			if !m.history.searching {
+				query = m.execInput.Value()
+			}
+
+			return m, m.searchHistory(query)
+
+		case key.Matches(msg, m.keys.snippets) && (m.currentView == 2 || m.currentView == 4):
 			if m.currentView == 2 {
+				if name := selectedName(m.displayList); name != "" {
+					m.currentContainer = name
+				}
+			}
+
+			m.snippetsParentView = m.currentView
+			m.currentView = 11 // switch to snippets view
+			m.displayList = updateDisplayList(m, snippetItemList())
+
+			return m, nil
+
+		case key.Matches(msg, m.keys.fanOut) && m.currentView == 1:
+			m.prompt = newPromptModal("fanout", "", "Exec in pods matching: SELECTOR [-c CONTAINER]", "app=web -c app or deploy/NAME")
+			return m, nil
+
+		case key.Matches(msg, m.keys.groupResults) && m.currentView == 6 && m.resultsAction == "Exec":
+			m.resultsGrouped = !m.resultsGrouped
+			m.displayList = updateDisplayList(m, m.resultItems())
+			return m, nil
+
+		case key.Matches(msg, m.keys.saveSnippet) && m.currentView == 4:
+			command := strings.TrimSpace(m.execInput.Value())
+			if command == "" {
+				return m, nil
+			}
+
+			m.prompt = newPromptModal("snippet", command, "Save \""+command+"\" as snippet named", "check DNS")
+			return m, nil
+
+		case key.Matches(msg, m.keys.search) && m.currentView == 3:
+			m.prompt = newPromptModal("search", "", "Search logs (regex)", "error|timeout")
+			return m, nil
+
+		case key.Matches(msg, m.keys.nextMatch) && m.currentView == 3:
+			m.jumpToMatch(true)
+			return m, nil
+
+		case key.Matches(msg, m.keys.prevMatch) && m.currentView == 3:
+			m.jumpToMatch(false)
+			return m, nil
+
+		case key.Matches(msg, m.keys.grep) && m.currentView == 3:
+			if m.search.pattern == nil {
+				return m, m.displayList.NewStatusMessage(statusMessageStyle("Search first to grep"))
+			}
+
+			m.displayList.ResetFilter()
+			m.search.grep = !m.search.grep
+
+			return m, m.applySearch()
+
+		case (key.Matches(msg, m.keys.moreContext) || key.Matches(msg, m.keys.lessContext)) && m.currentView == 3 && m.search.grep:
+			if key.Matches(msg, m.keys.moreContext) {
+				m.search.context++
+			} else if m.search.context > 0 {
+				m.search.context--
+			}
+
+			return m, m.applySearch()
+
+		case key.Matches(msg, m.keys.pretty) && m.currentView == 3:
+			m.search.raw = !m.search.raw
+			return m, m.applySearch()
+
+		case key.Matches(msg, m.keys.expand) && m.currentView == 3:
+			i, ok := m.displayList.SelectedItem().(item)
+			if !ok {
+				return m, nil
+			}
+
+			parsed, ok := parseLogLine(string(i))
+			if !ok {
+				return m, m.displayList.NewStatusMessage(statusMessageStyle("Not a JSON or logfmt line"))
+			}
+			m.detail = parsed.expand()
+
+			return m, nil
+
+		case key.Matches(msg, m.keys.fieldFilter) && m.currentView == 3:
+			m.prompt = newPromptModal("fields", "", "Filter structured lines by field (empty clears)", "level=error app!=web")
+			return m, nil
+
+		case key.Matches(msg, m.keys.save) && (m.currentView == 3 || m.currentView == 5):
+			m.prompt = newPromptModal("save", "", "Save to file: [buffer|filtered|full] PATH (a .gz path is compressed)", "full ~/logs/app.log.gz")
+			return m, nil
+
+		case key.Matches(msg, m.keys.yank) && m.currentView != 4:
+			text := yankText(m)
+			if m.currentView <= 2 {
+				// Lists of resources copy the name of the selected one
+				text = selectedName(m.displayList)
+			}
+			m.rangeActive = false
+
+			return m, copyToClipboard(text, fmt.Sprintf("Copied %d line(s)", strings.Count(text, "\n")+1))
+
+		case key.Matches(msg, m.keys.yankRange) && (m.currentView == 3 || m.currentView == 5 || m.currentView == 8):
+			m.rangeActive = true
+			m.rangeStart = m.displayList.Index()
+			m.rangeView = m.currentView
+
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Range started, move and press y to copy"))
+
+		case key.Matches(msg, m.keys.yankManifest) && (m.currentView == 0 || m.currentView == 1 || m.currentView == 14):
+			kind, namespace, name, ok := m.selectedObject()
+			if !ok {
+				return m, nil
+			}
+
+			manifest, err := GetManifest(m.conn, kind, namespace, name)
+			if err != nil {
+				return m, m.displayList.NewStatusMessage(statusMessageStyle("Copy failed: " + err.Error()))
+			}
+			return m, copyToClipboard(manifest, fmt.Sprintf("Copied %s/%s manifest", kind, name))
+
+		case key.Matches(msg, m.keys.aggregateLogs) && m.currentView == 1:
+			if !m.scopeToSelected() {
+				return m, nil
+			}
+
+			m.currentView = 16 // switch to workload picker
+			m.displayList = updateDisplayList(m, workloadItemList(m.conn.clientset, m.currentNamespace))
+			return m, nil
+
+		case key.Matches(msg, m.keys.aggregateLogs) && m.currentView == 16:
+			m.prompt = newPromptModal("aggregate", "", "Aggregate logs of pods in "+m.currentNamespace+" matching", "app=web,tier!=cache")
+			return m, nil
+
+		case key.Matches(msg, m.keys.forwards) && (m.currentView <= 3 || m.currentView == 15):
+			m.forwardsParentView = m.currentView
+			m.currentView = 7 // switch to port forward view
+			m.displayList = updateDisplayList(m, m.forwards.items())
+
+			m.forwardsTick++
+			return m, portForwardTick(m.forwardsTick)
+
+		case key.Matches(msg, m.keys.restart) && m.currentView == 1:
+			targets := selectedTargets(m)
+			clientset, namespace := m.conn.clientset, m.currentNamespace
+			m.resultsParentView = 1
+
+			cmd := m.displayList.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Restarting %d pod(s)...", len(targets))))
+			return m, tea.Batch(cmd, bulkCmd("Restart", targets, func(target string) (string, error) {
+				namespace, target := splitTarget(namespace, target)
+				err := RestartPod(clientset, namespace, target)
+				if err != nil {
+					return "", err
+				}
+
+				return "deleted, waiting for controller to recreate it", nil
+			}))
+
+		case key.Matches(msg, m.keys.bulkLogs) && m.currentView == 1:
+			targets := selectedTargets(m)
+			clientset, namespace := m.conn.clientset, m.currentNamespace
+			m.resultsParentView = 1
+
+			cmd := m.displayList.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Fetching logs of %d pod(s)...", len(targets))))
+			return m, tea.Batch(cmd, bulkCmd("Logs", targets, func(target string) (string, error) {
+				namespace, target := splitTarget(namespace, target)
+				containerName, err := DefaultContainer(clientset, namespace, target)
+				if err != nil {
+					return "", err
+				}
+
+				return FetchLogs(clientset, namespace, target, containerName)
+			}))
+
+		case key.Matches(msg, m.keys.exec):
+			if m.currentView == 1 {
+				targets := selectedTargets(m)
+				if len(targets) == 0 {
+					return m, nil
+				}
+
+				m.prompt = newPromptModal("execcontainer", strings.Join(targets, ","), fmt.Sprintf("Exec in %d pod(s), container", len(targets)), "empty for each pod's default container")
+				return m, nil
+			} else if m.currentView == 2 {
 				// Get selected container
-				i, ok := m.displayList.SelectedItem().(item)
-				if ok {
-					m.currentContainer = string(i)
+				if name := selectedName(m.displayList); name != "" {
+					m.currentContainer = name
 				}
 
-				m.execResult = ""
+				m.execBytes = 0
 				m.execError = ""
 				m.execInput.Reset()
 				m.execInput.SetValue("")
@@ -192,11 +989,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				return m, nil
 			} else if m.currentView == 5 {
+				m.stopExec()
 				m.currentView = 2 // Temporarily set view to Container while it loads
-				containerItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				containerItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, containerItemList)
 
-				m.execResult = ""
+				m.execBytes = 0
 				m.execError = ""
 				m.execInput.Reset()
 				m.execInput.SetValue("")
@@ -214,75 +1012,139 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch m.currentView {
 			case 0:
 				m.currentNamespace = string(i)
+				m.allNamespaces = false
+				m.tree = nil
 				m.namespaceList = m.displayList
+				m.podUsage = nil
 				m.currentView = 1 // switch to pod view
-				podItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				podItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, podItemList)
 			case 1:
-				m.currentPod = string(i)
+				m.currentNamespace, m.currentPod = splitTarget(m.currentNamespace, string(i))
 				m.podList = m.displayList
 				m.currentView = 2 // switch to container view
-				containerItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				containerItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, containerItemList)
 			case 2:
-				m.currentContainer = string(i)
+				m.currentContainer = selectedName(m.displayList)
 				m.containerList = m.displayList
+				m.search = logSearch{}
+				m.logViewport = logViewport{wrap: m.logViewport.wrap}
+				m.logTimestamps = false
 				m.currentView = 3 // switch to log view
-				logItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				logItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
 				m.displayList = updateDisplayList(m, logItemList)
 			case 3:
 				m.currentLog = string(i)
 			case 4:
-				command := m.execInput.Value()
+				return m.runExec(m.execInput.Value())
+			case 5:
+				m.currentLog = string(i)
+				// m.currentView = 2
+				// containerItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+				// m.displayList = updateDisplayList(m, containerItemList)
+			case 14:
+				row, ok := m.displayList.SelectedItem().(treeRow)
+				if !ok {
+					return m, nil
+				}
 
-				containerName := m.currentContainer
-				podName := m.currentPod
-				namespace := m.currentNamespace
+				switch row.node.kind {
+				case "Pod":
+					m.currentPod = row.node.pod
+					m.currentView = 2 // switch to container view
+					containerItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+					m.displayList = updateDisplayList(m, containerItemList)
+				case "Container":
+					m.currentPod, m.currentContainer = row.node.pod, row.node.name
+					m.search = logSearch{}
+					m.logViewport = logViewport{wrap: m.logViewport.wrap}
+					m.logTimestamps = false
+					m.currentView = 3 // switch to log view
+					logItemList := listToItemList(m.conn.clientset, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
+					m.displayList = updateDisplayList(m, logItemList)
+				default:
+					m.toggleTreeRow()
+					return m, nil
+				}
+			case 16:
+				if !ok {
+					return m, nil
+				}
 
-				output, stderr, err := ExecToPodThroughAPI(command, containerName, podName, namespace, nil)
+				return m.runPrompt("aggregate", "", string(i))
+			case 15:
+				svc, ok := m.displayList.SelectedItem().(serviceItem)
+				if !ok {
+					return m, nil
+				}
 
-				if len(stderr) != 0 {
-					fmt.Println("STDERR:", stderr)
+				m.prompt = newPromptModal("portforward", "svc/"+svc.name, "Port-forward svc/"+svc.name+" ("+svc.ports+"): LOCAL:REMOTE", "8080:80 or 8080:http")
+				return m, nil
+			case 11:
+				s, ok := m.displayList.SelectedItem().(snippet)
+				if !ok {
+					return m, nil
 				}
-				if err != nil {
-					m.execError = fmt.Sprintf("Error occured while `exec`ing to the Pod %q, container %q, namespace %q, command %q. Error: %+v\n", podName, containerName, namespace, command, err)
-					output = m.execError
-				} else {
-					m.execResult = output
+
+				m.execInput.SetValue(s.Command)
+				return m.runExec(s.Command)
+			case 9:
+				entry, remotePath, ok := m.selectedPath()
+				if !ok {
+					return m, nil
 				}
 
-				var execResultItemList []list.Item
-				execResultList := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
-				for _, listData := range execResultList {
-					execResultItemList = append(execResultItemList, item(listData))
+				if entry.dir {
+					return m, listFilesCmd(m.conn, m.currentNamespace, m.currentPod, m.currentContainer, remotePath)
 				}
 
-				if len(execResultItemList) > 0 {
-					execResultItemList = execResultItemList[:len(execResultItemList)-1]
+				return m, openFileCmd(m.conn, m.currentNamespace, m.currentPod, m.currentContainer, remotePath, entry.link != "")
+			case 6:
+				if m.resultsAction == "Exec" {
+					title, lines := m.resultDetail(m.displayList.GlobalIndex())
+					if lines != nil {
+						m = m.openPager(title, lines, 6)
+					}
+					return m, nil
 				}
 
-				m.displayList = updateDisplayList(m, execResultItemList)
-				m.currentView = 5
-			case 5:
+				// Show the full output of the selected row
 				m.currentLog = string(i)
-				// m.currentView = 2
-				// containerItemList := listToItemList(m.kubeContext, m.currentNamespace, m.currentView, m.currentPod, m.currentContainer)
-				// m.displayList = updateDisplayList(m, containerItemList)
+				if n := m.displayList.GlobalIndex(); n < len(m.bulkResults) {
+					result := m.bulkResults[n]
+					m.currentLog = result.output
+					if result.err != nil {
+						m.currentLog = result.err.Error()
+					}
+				}
 			}
 
-			return m, nil
+			return m, m.refreshColumns()
 		}
 
 	}
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.currentView == 13 &&
+		!key.Matches(keyMsg, m.displayList.KeyMap.Quit, m.displayList.KeyMap.ForceQuit) {
+		// The chart hides the list, so it must not move or filter it
+		return m, tea.Batch(cmds...)
+	}
+
 	if m.currentView != 4 {
 		// This will also call our delegate's update function.
 		newListModel, cmd := m.displayList.Update(msg)
 		m.displayList = newListModel
 		cmds = append(cmds, cmd)
 	} else {
+		before := m.execInput.Value()
 		m.execInput, cmd = m.execInput.Update(msg)
 		cmds = append(cmds, cmd)
+
+		// Editing the recalled command starts a new one
+		if m.execInput.Value() != before {
+			m.history.reset()
+		}
 	}
 
 	return m, tea.Batch(cmds...)
@@ -300,21 +1162,70 @@ func (m model) View() string {
 		content = ""
 	} else if m.currentView == 3 {
 		content = m.currentLog
+		if status := m.search.status(); status != "" {
+			content = status + "\n" + content
+		}
 	} else if m.currentView == 4 {
 		content = m.execInput.View()
-	} else if m.currentView == 5 {
+		if m.history.searching {
+			content = fmt.Sprintf("(reverse-i-search)`%s'\n%s", m.history.query, content)
+		}
+		if len(m.execTargets) > 0 {
+			target := "default container"
+			if m.execContainer != "" {
+				target = "container " + m.execContainer
+			}
+			content = fmt.Sprintf("Run in %d pod(s), %s\n%s", len(m.execTargets), target, content)
+		}
+		content += "\n\n" + m.displayList.Help.ShortHelpView([]key.Binding{
+			m.keys.historyPrev,
+			m.keys.historyNext,
+			m.keys.historySearch,
+			m.keys.snippets,
+			m.keys.saveSnippet,
+		})
+	} else if m.currentView == 5 || m.currentView == 6 {
+		content = m.currentLog
+	} else if m.currentView == 9 {
 		content = m.currentLog
 	}
+	if m.transferStatus != "" && (m.currentView == 3 || m.currentView == 5 || m.currentView == 9) {
+		content = m.transferStatus + "\n" + content
+	}
 
 	textBlock := style.Render(content)
+	if m.deleteModal.active {
+		textBlock = m.deleteModal.View(m.conn.contextName)
+	} else if m.prompt.active {
+		textBlock = m.prompt.View()
+	} else if m.detail != "" {
+		textBlock = modalStyle.Render(m.detail + "\n\n[any key] close")
+	}
 	block := lipgloss.PlaceHorizontal(m.containerWidth, lipgloss.Center, textBlock)
-	view := lipgloss.JoinVertical(lipgloss.Top, appStyle.Render("\n"+m.displayList.View()), block)
+	listView := m.displayList.View()
+	if m.currentView == 13 {
+		listView = m.chartView()
+	} else if m.inPager() {
+		listView = m.logViewport.View(m.displayList, m.pagerDelegate())
+	}
+	view := lipgloss.JoinVertical(lipgloss.Top, appStyle.Render("\n"+listView), block)
 
 	return view
 }
 
 func main() {
-	if _, err := tea.NewProgram(newModel(), tea.WithAltScreen()).Run(); err != nil {
+	flag.Parse()
+
+	// Home directories are only looked up for the paths that use them
+	for _, path := range []*string{kubeconfigPath, execHistoryPath, snippetsPath} {
+		*path = expandHome(*path)
+	}
+
+	final, err := tea.NewProgram(newModel(), tea.WithAltScreen()).Run()
+	if m, ok := final.(model); ok {
+		m.dropExecSpool()
+	}
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Orders of the Pods and Nodes views.
+const (
+	sortByName = iota
+	sortByCPU
+	sortByMemory
+)
+
+var sortNames = []string{"name", "CPU", "memory"}
+
+// resourceUsage is the CPU (millicores) and memory (bytes) in use, with the
+// requests and limits it is compared against. Zero means not set.
+type resourceUsage struct {
+	cpu           int64
+	memory        int64
+	cpuRequest    int64
+	cpuLimit      int64
+	memoryRequest int64
+	memoryLimit   int64
+}
+
+// usageWidth is the width of a container's usage column with its sparklines,
+// for aligning columns.
+var usageWidth = len(resourceUsage{}.String()) + 2 + 2*sparklineWidth + 1
+
+func (u resourceUsage) String() string {
+	return fmt.Sprintf("cpu %6s %-9s  mem %9s %-9s",
+		fmt.Sprintf("%dm", u.cpu), percents(u.cpu, u.cpuRequest, u.cpuLimit),
+		humanBytes(u.memory), percents(u.memory, u.memoryRequest, u.memoryLimit))
+}
+
+// percents shows usage as a percentage of the request and the limit.
+func percents(used int64, request int64, limit int64) string {
+	percent := func(of int64) string {
+		if of == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d%%", used*100/of)
+	}
+
+	return percent(request) + "/" + percent(limit)
+}
+
+// podUsage is the usage of a pod and each of its containers.
+type podUsage struct {
+	resourceUsage
+	containers map[string]resourceUsage
+}
+
+// nodeUsage is the usage of a node against what it can allocate to pods.
+type nodeUsage struct {
+	cpu               int64
+	memory            int64
+	allocatableCPU    int64
+	allocatableMemory int64
+}
+
+func (u nodeUsage) String() string {
+	percent := func(used int64, of int64) string {
+		if of == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d%%", used*100/of)
+	}
+
+	return fmt.Sprintf("cpu %7s %4s of %-7s  mem %9s %4s of %s",
+		fmt.Sprintf("%dm", u.cpu), percent(u.cpu, u.allocatableCPU), fmt.Sprintf("%dm", u.allocatableCPU),
+		humanBytes(u.memory), percent(u.memory, u.allocatableMemory), humanBytes(u.allocatableMemory))
+}
+
+// metricsList is the part of a PodMetricsList or NodeMetricsList kuco reads.
+type metricsList struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta   `json:"metadata"`
+		Usage      corev1.ResourceList `json:"usage"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// getMetrics reads metrics.k8s.io/v1beta1 directly, so kuco does not need the
+// metrics client. A missing metrics-server is reported as such.
+func getMetrics(clientset *kubernetes.Clientset, path ...string) (*metricsList, error) {
+	data, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath(append([]string{"/apis/metrics.k8s.io/v1beta1"}, path...)...).
+		DoRaw(context.TODO())
+	if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+		return nil, fmt.Errorf("metrics API not available, is metrics-server installed?")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics metricsList
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, err
+	}
+
+	return &metrics, nil
+}
+
+// GetPodUsage returns the usage of every pod in a namespace with metrics,
+// keyed like the Pods view names them.
+func GetPodUsage(clientset *kubernetes.Clientset, namespace string) (map[string]podUsage, error) {
+	path := []string{"pods"}
+	if namespace != metav1.NamespaceAll {
+		path = []string{"namespaces", namespace, "pods"}
+	}
+	metrics, err := getMetrics(clientset, path...)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	specs := map[string]corev1.PodSpec{}
+	for _, pod := range pods.Items {
+		specs[qualifiedName(namespace, pod.Namespace, pod.Name)] = pod.Spec
+	}
+
+	usage := map[string]podUsage{}
+	for _, podMetrics := range metrics.Items {
+		name := qualifiedName(namespace, podMetrics.Metadata.Namespace, podMetrics.Metadata.Name)
+		spec, ok := specs[name]
+		if !ok {
+			continue
+		}
+
+		resources := map[string]corev1.ResourceRequirements{}
+		for _, container := range spec.Containers {
+			resources[container.Name] = container.Resources
+		}
+
+		pod := podUsage{containers: map[string]resourceUsage{}}
+		limited := true
+		for _, container := range podMetrics.Containers {
+			r := resources[container.Name]
+			c := resourceUsage{
+				cpu:           container.Usage.Cpu().MilliValue(),
+				memory:        container.Usage.Memory().Value(),
+				cpuRequest:    r.Requests.Cpu().MilliValue(),
+				cpuLimit:      r.Limits.Cpu().MilliValue(),
+				memoryRequest: r.Requests.Memory().Value(),
+				memoryLimit:   r.Limits.Memory().Value(),
+			}
+			pod.containers[container.Name] = c
+
+			pod.cpu += c.cpu
+			pod.memory += c.memory
+			pod.cpuRequest += c.cpuRequest
+			pod.memoryRequest += c.memoryRequest
+			pod.cpuLimit += c.cpuLimit
+			pod.memoryLimit += c.memoryLimit
+			limited = limited && c.cpuLimit > 0 && c.memoryLimit > 0
+		}
+		// A pod with an unlimited container has no limit as a whole
+		if !limited {
+			pod.cpuLimit, pod.memoryLimit = 0, 0
+		}
+
+		usage[name] = pod
+	}
+
+	return usage, nil
+}
+
+// GetNodeUsage returns the usage of every node with metrics.
+func GetNodeUsage(clientset *kubernetes.Clientset) (map[string]nodeUsage, error) {
+	metrics, err := getMetrics(clientset, "nodes")
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	allocatable := map[string]corev1.ResourceList{}
+	for _, node := range nodes.Items {
+		allocatable[node.Name] = node.Status.Allocatable
+	}
+
+	usage := map[string]nodeUsage{}
+	for _, nodeMetrics := range metrics.Items {
+		name := nodeMetrics.Metadata.Name
+		capacity := allocatable[name]
+		usage[name] = nodeUsage{
+			cpu:               nodeMetrics.Usage.Cpu().MilliValue(),
+			memory:            nodeMetrics.Usage.Memory().Value(),
+			allocatableCPU:    capacity.Cpu().MilliValue(),
+			allocatableMemory: capacity.Memory().Value(),
+		}
+	}
+
+	return usage, nil
+}
+
+// GetNodes lists the names of the cluster's nodes.
+func GetNodes(clientset *kubernetes.Clientset) ([]string, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, node := range nodes.Items {
+		names = append(names, node.Name)
+	}
+
+	return names, nil
+}
+
+// nodeItem is a row of the Nodes view.
+type nodeItem struct {
+	name  string
+	usage *nodeUsage
+}
+
+func (n nodeItem) Title() string {
+	if n.usage == nil {
+		return n.name
+	}
+
+	return fmt.Sprintf("%-40s  %s", n.name, n.usage.String())
+}
+func (n nodeItem) FilterValue() string { return n.name }
+
+// nodeItemList lists the nodes for the Nodes view, showing errors as an entry.
+func nodeItemList(clientset *kubernetes.Clientset) []list.Item {
+	names, err := GetNodes(clientset)
+	if err != nil {
+		return []list.Item{item(err.Error())}
+	}
+
+	itemList := []list.Item{}
+	for _, name := range names {
+		itemList = append(itemList, nodeItem{name: name})
+	}
+
+	return itemList
+}
+
+// usageMsg delivers the usage fetched for a view.
+type usageMsg struct {
+	view      int
+	namespace string
+	pods      map[string]podUsage
+	nodes     map[string]nodeUsage
+	err       error
+}
+
+type metricsTickMsg struct{}
+
+func metricsTick() tea.Cmd {
+	return tea.Tick(*metricsInterval, func(time.Time) tea.Msg {
+		return metricsTickMsg{}
+	})
+}
+
+// refreshColumns fetches the live columns of the current view, if it has any:
+// namespace details or usage.
+func (m model) refreshColumns() tea.Cmd {
+	clientset, namespace, view := m.conn.clientset, m.currentNamespace, m.currentView
+
+	switch view {
+	case 0:
+		return func() tea.Msg {
+			return GetNamespaceInfo(clientset)
+		}
+	case 1, 2, 13:
+		return func() tea.Msg {
+			pods, err := GetPodUsage(clientset, namespace)
+			return usageMsg{view: view, namespace: namespace, pods: pods, err: err}
+		}
+	case 12:
+		return func() tea.Msg {
+			nodes, err := GetNodeUsage(clientset)
+			return usageMsg{view: view, nodes: nodes, err: err}
+		}
+	}
+
+	return nil
+}
+
+// applyUsage shows freshly fetched usage in the current view.
+func (m *model) applyUsage(msg usageMsg) tea.Cmd {
+	if msg.err != nil {
+		// Say it once rather than on every refresh
+		if m.usageErr == msg.err.Error() {
+			return nil
+		}
+		m.usageErr = msg.err.Error()
+		return m.displayList.NewStatusMessage(statusMessageStyle("No usage: " + m.usageErr))
+	}
+	m.usageErr = ""
+	if msg.pods != nil {
+		m.usageHistory.record(msg.namespace, msg.pods, time.Now())
+	}
+
+	switch m.currentView {
+	case 1:
+		m.podUsage = msg.pods
+		m.displayList.Title = m.usageTitle(m.podsTitle())
+		return m.sortByUsage()
+
+	case 2:
+		usage := msg.pods[m.currentPod]
+		itemList := m.displayList.Items()
+		for n, listItem := range itemList {
+			c, ok := listItem.(containerItem)
+			if !ok {
+				continue
+			}
+
+			c.Usage = ""
+			if containerUsage, ok := usage.containers[c.Name]; ok {
+				c.Usage = containerUsage.String() + "  " + m.usageHistory.sparklines(usageKey(m.currentNamespace, m.currentPod)+"/"+c.Name)
+			}
+			itemList[n] = c
+		}
+		return m.displayList.SetItems(itemList)
+
+	case 12:
+		m.nodeUsage = msg.nodes
+		return m.sortByUsage()
+
+	case 13:
+		m.podUsage = msg.pods
+	}
+
+	return nil
+}
+
+// usageTitle names the order of the rows and explains the usage columns.
+func (m model) usageTitle(title string) string {
+	if m.usageSort != sortByName {
+		title += " by " + sortNames[m.usageSort]
+	}
+	if m.currentView == 1 && m.podUsage != nil {
+		title += " (usage % of request/limit)"
+	}
+
+	return title
+}
+
+// sortByUsage fills the usage columns of the Pods or Nodes view and orders the
+// rows by the chosen column, highest usage first.
+func (m *model) sortByUsage() tea.Cmd {
+	itemList := slices.Clone(m.displayList.Items())
+
+	key := func(listItem list.Item) (int64, int64) {
+		switch i := listItem.(type) {
+		case item:
+			usage := m.podUsage[string(i)]
+			return usage.cpu, usage.memory
+		case nodeItem:
+			usage := m.nodeUsage[i.name]
+			return usage.cpu, usage.memory
+		}
+		return 0, 0
+	}
+
+	for n, listItem := range itemList {
+		if i, ok := listItem.(nodeItem); ok {
+			if usage, ok := m.nodeUsage[i.name]; ok {
+				i.usage = &usage
+			}
+			itemList[n] = i
+		}
+	}
+	clear(m.columns)
+	if m.currentView == 1 {
+		m.podColumns(itemList)
+	}
+
+	sort.SliceStable(itemList, func(a, b int) bool {
+		cpuA, memoryA := key(itemList[a])
+		cpuB, memoryB := key(itemList[b])
+		switch m.usageSort {
+		case sortByCPU:
+			return cpuA > cpuB
+		case sortByMemory:
+			return memoryA > memoryB
+		}
+		return strings.Compare(itemList[a].FilterValue(), itemList[b].FilterValue()) < 0
+	})
+
+	return setItemsKeepingSelection(&m.displayList, itemList)
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var namespacesInterval = flag.Duration("namespaces-interval", time.Minute, "how often namespace details are refreshed while the Namespaces view is open")
+
+// columnRow replaces the plain name of a list entry. Alerting rows, such as
+// Terminating namespaces, stand out.
+type columnRow struct {
+	text  string
+	alert bool
+}
+
+// namespaceInfo is what the Namespaces view shows beyond the name. Pod counts
+// are nil when the pods could not be listed.
+type namespaceInfo struct {
+	phase   corev1.NamespacePhase
+	created time.Time
+	labels  map[string]string
+	pods    map[corev1.PodPhase]int
+	quota   []string
+}
+
+// namespacesMsg delivers the details of every namespace. Pod counts and quota
+// usage are optional and fail on their own.
+type namespacesMsg struct {
+	namespaces map[string]namespaceInfo
+	err        error
+	podsErr    error
+	quotaErr   error
+}
+
+type namespacesTickMsg struct{}
+
+func namespacesTick() tea.Cmd {
+	return tea.Tick(*namespacesInterval, func(time.Time) tea.Msg {
+		return namespacesTickMsg{}
+	})
+}
+
+// blockersMsg delivers what is holding up the deletion of a namespace.
+type blockersMsg struct {
+	namespace string
+	lines     []string
+	err       error
+}
+
+// GetNamespaceInfo collects phase, age, labels, pod counts and quota usage for
+// every namespace with three list calls. Only the namespace list is required;
+// without the pod or quota list the namespaces are shown without that column.
+func GetNamespaceInfo(clientset *kubernetes.Clientset) namespacesMsg {
+	namespaces, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return namespacesMsg{err: err}
+	}
+
+	info := map[string]namespaceInfo{}
+	for _, ns := range namespaces.Items {
+		info[ns.Name] = namespaceInfo{
+			phase:   ns.Status.Phase,
+			created: ns.CreationTimestamp.Time,
+			labels:  ns.Labels,
+		}
+	}
+	msg := namespacesMsg{namespaces: info}
+
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		msg.podsErr = err
+	} else {
+		for name, ns := range info {
+			ns.pods = map[corev1.PodPhase]int{}
+			info[name] = ns
+		}
+		for _, pod := range pods.Items {
+			if ns, ok := info[pod.Namespace]; ok {
+				ns.pods[pod.Status.Phase]++
+			}
+		}
+	}
+
+	quotas, err := clientset.CoreV1().ResourceQuotas(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		msg.quotaErr = err
+		return msg
+	}
+	for _, quota := range quotas.Items {
+		ns, ok := info[quota.Namespace]
+		if !ok {
+			continue
+		}
+
+		var resources []string
+		for resource := range quota.Status.Hard {
+			resources = append(resources, string(resource))
+		}
+		sort.Strings(resources)
+		for _, resource := range resources {
+			hard := quota.Status.Hard[corev1.ResourceName(resource)]
+			used := quota.Status.Used[corev1.ResourceName(resource)]
+			ns.quota = append(ns.quota, fmt.Sprintf("%s %s/%s", resource, used.String(), hard.String()))
+		}
+		info[quota.Namespace] = ns
+	}
+
+	return msg
+}
+
+// humanAge shortens a duration the way kubectl shows ages.
+func humanAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
+func (n namespaceInfo) row(name string, width int) columnRow {
+	var pods []string
+	for _, phase := range []corev1.PodPhase{corev1.PodRunning, corev1.PodPending, corev1.PodSucceeded, corev1.PodFailed, corev1.PodUnknown} {
+		if count := n.pods[phase]; count > 0 {
+			pods = append(pods, fmt.Sprintf("%d %s", count, phase))
+		}
+	}
+	switch {
+	case n.pods == nil:
+		pods = []string{"pods unknown"}
+	case len(pods) == 0:
+		pods = []string{"no pods"}
+	}
+
+	var labels []string
+	for k, v := range n.labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+
+	text := fmt.Sprintf("%-*s  %-11s  %4s  %-28s", width, name, n.phase, humanAge(time.Since(n.created)), strings.Join(pods, ", "))
+	if len(n.quota) > 0 {
+		text += "  quota " + strings.Join(n.quota, ", ")
+	}
+	if len(labels) > 0 {
+		text += "  " + strings.Join(labels, ",")
+	}
+
+	return columnRow{text: text, alert: n.phase == corev1.NamespaceTerminating}
+}
+
+// applyNamespaces fills the columns of the Namespaces view.
+func (m *model) applyNamespaces(msg namespacesMsg) tea.Cmd {
+	var problems []error
+	if msg.err != nil {
+		problems = append(problems, fmt.Errorf("no namespace details: %w", msg.err))
+	}
+	if msg.podsErr != nil {
+		problems = append(problems, fmt.Errorf("no pod counts: %w", msg.podsErr))
+	}
+	if msg.quotaErr != nil {
+		problems = append(problems, fmt.Errorf("no quota usage: %w", msg.quotaErr))
+	}
+
+	// Say it once rather than on every refresh
+	var cmd tea.Cmd
+	namespacesErr := ""
+	if len(problems) > 0 {
+		namespacesErr = errors.Join(problems...).Error()
+	}
+	if namespacesErr != "" && namespacesErr != m.namespacesErr {
+		cmd = m.displayList.NewStatusMessage(statusMessageStyle(strings.ReplaceAll(namespacesErr, "\n", "; ")))
+	}
+	m.namespacesErr = namespacesErr
+	if msg.err != nil {
+		return cmd
+	}
+
+	width := 0
+	for name := range msg.namespaces {
+		width = max(width, len(name))
+	}
+
+	clear(m.columns)
+	for name, info := range msg.namespaces {
+		m.columns[name] = info.row(name, width)
+	}
+
+	return cmd
+}
+
+// NamespaceBlockers explains why a namespace is stuck terminating: its
+// finalizers, the conditions the namespace controller reports and every
+// object left in it, with that object's own finalizers.
+func NamespaceBlockers(conn *kubeConnection, name string) ([]string, error) {
+	clientset := conn.clientset
+	ns, err := clientset.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{fmt.Sprintf("Namespace %s is %s", name, ns.Status.Phase)}
+	if ns.DeletionTimestamp != nil {
+		lines = append(lines, fmt.Sprintf("Deletion requested %s ago", humanAge(time.Since(ns.DeletionTimestamp.Time))))
+	}
+
+	lines = append(lines, "", "Finalizers:")
+	for _, finalizer := range ns.Spec.Finalizers {
+		lines = append(lines, "  spec: "+string(finalizer))
+	}
+	for _, finalizer := range ns.Finalizers {
+		lines = append(lines, "  metadata: "+finalizer)
+	}
+
+	lines = append(lines, "", "Conditions:")
+	for _, condition := range ns.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			lines = append(lines, fmt.Sprintf("  %s: %s", condition.Type, condition.Message))
+		}
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(conn.config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Discovery can fail for a single broken API group, which is itself a
+	// common reason for a namespace to hang, so partial results are used
+	resourceLists, discoveryErr := clientset.Discovery().ServerPreferredNamespacedResources()
+	lines = append(lines, "", "Remaining resources:")
+	if discoveryErr != nil {
+		lines = append(lines, "  discovery incomplete: "+discoveryErr.Error())
+	}
+
+	remaining := 0
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			if !canList(resource.Verbs) || strings.Contains(resource.Name, "/") {
+				continue
+			}
+
+			objects, err := dynamicClient.Resource(gv.WithResource(resource.Name)).Namespace(name).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				lines = append(lines, fmt.Sprintf("  %s: %v", resource.Name, err))
+				continue
+			}
+
+			for _, object := range objects.Items {
+				remaining++
+				line := fmt.Sprintf("  %s/%s", resource.Kind, object.GetName())
+				if finalizers := object.GetFinalizers(); len(finalizers) > 0 {
+					line += "  finalizers: " + strings.Join(finalizers, ", ")
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	if remaining == 0 {
+		lines = append(lines, "  none")
+	}
+
+	return lines, nil
+}
+
+func canList(verbs metav1.Verbs) bool {
+	for _, verb := range verbs {
+		if verb == "list" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// qualifiedName names a pod listed from namespace. Pods listed from all
+// namespaces are named namespace/pod, as names only repeat across namespaces.
+func qualifiedName(namespace string, podNamespace string, name string) string {
+	if namespace == metav1.NamespaceAll {
+		return podNamespace + "/" + name
+	}
+
+	return name
+}
+
+// splitTarget returns the namespace and name of a pod named by qualifiedName.
+func splitTarget(namespace string, target string) (string, string) {
+	if podNamespace, name, ok := strings.Cut(target, "/"); ok {
+		return podNamespace, name
+	}
+
+	return namespace, target
+}
+
+// usageKey identifies a pod in the usage history the same way in either mode.
+func usageKey(namespace string, target string) string {
+	namespace, name := splitTarget(namespace, target)
+	return namespace + "/" + name
+}
+
+// scopeToSelected narrows the Pods view of all namespaces to the namespace of
+// the selected pod, for the views that work within one namespace. Going back
+// to the Pods view lists all namespaces again.
+func (m *model) scopeToSelected() bool {
+	if !m.allNamespaces {
+		return true
+	}
+
+	namespace, _ := splitTarget("", selectedName(m.displayList))
+	if namespace == "" {
+		return false
+	}
+
+	m.currentNamespace = namespace
+	return true
+}
+
+// podsTitle is the title of the Pods view.
+func (m model) podsTitle() string {
+	if m.allNamespaces {
+		return "[KUCO] Pods in all namespaces"
+	}
+
+	return "[KUCO] Pods"
+}
+
+// podColumns lays out the rows of the Pods view: a NAMESPACE column when the
+// pods of all namespaces are listed, the name and the usage if known.
+func (m model) podColumns(itemList []list.Item) {
+	nsWidth, width := 0, 0
+	for _, listItem := range itemList {
+		if i, ok := listItem.(item); ok {
+			namespace, name := splitTarget("", string(i))
+			nsWidth, width = max(nsWidth, len(namespace)), max(width, len(name))
+		}
+	}
+
+	for _, listItem := range itemList {
+		i, ok := listItem.(item)
+		if !ok {
+			continue
+		}
+
+		namespace, name := splitTarget("", string(i))
+		text := fmt.Sprintf("%-*s", width, name)
+		if nsWidth > 0 {
+			text = fmt.Sprintf("%-*s  %s", nsWidth, namespace, text)
+		}
+
+		usage, ok := m.podUsage[string(i)]
+		if ok {
+			text += "  " + usage.String() + "  " + m.usageHistory.sparklines(usageKey(m.currentNamespace, string(i)))
+		} else if nsWidth == 0 {
+			continue
+		}
+		m.columns[string(i)] = columnRow{text: text}
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// portForward is a single running forward. It is shared between the model and
+// the goroutine serving it, so its mutable fields are guarded by mu.
+type portForward struct {
+	target     string
+	namespace  string
+	pod        string
+	remotePort int
+	stopChan   chan struct{}
+	stopOnce   sync.Once
+
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+
+	mu        sync.Mutex
+	localPort int
+	status    string
+}
+
+func (pf *portForward) setStatus(status string) {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	pf.status = status
+}
+
+func (pf *portForward) stop() {
+	pf.stopOnce.Do(func() { close(pf.stopChan) })
+}
+
+// Write records errors reported for individual connections of the forward.
+func (pf *portForward) Write(p []byte) (int, error) {
+	pf.setStatus("active, last error: " + strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (pf *portForward) String() string {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+
+	return fmt.Sprintf("%-40s  localhost:%-5d -> %-5d  in %-9s  out %-9s  %s",
+		pf.target, pf.localPort, pf.remotePort,
+		humanBytes(pf.bytesIn.Load()), humanBytes(pf.bytesOut.Load()), pf.status)
+}
+
+// portForwardManager keeps every forward alive independently of the view
+// being displayed.
+type portForwardManager struct {
+	mu       sync.Mutex
+	forwards []*portForward
+}
+
+func (m *portForwardManager) add(pf *portForward) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.forwards = append(m.forwards, pf)
+}
+
+func (m *portForwardManager) remove(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n < 0 || n >= len(m.forwards) {
+		return
+	}
+
+	m.forwards[n].stop()
+	m.forwards = append(m.forwards[:n], m.forwards[n+1:]...)
+}
+
+func (m *portForwardManager) items() []list.Item {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var itemList []list.Item
+	for _, pf := range m.forwards {
+		itemList = append(itemList, item(pf.String()))
+	}
+
+	return itemList
+}
+
+type portForwardStartedMsg struct {
+	forward *portForward
+	err     error
+}
+
+// portForwardTickMsg refreshes the forwards view. Ticks of an earlier visit
+// of the view carry an older generation and end their chain.
+type portForwardTickMsg struct {
+	generation int
+}
+
+func portForwardTick(generation int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return portForwardTickMsg{generation: generation} })
+}
+
+// parseForwardSpec splits "local:remote" into its parts. A bare port forwards
+// the same port locally.
+func parseForwardSpec(spec string) (int, string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.ContainsAny(spec, " \t") {
+		return 0, "", fmt.Errorf("expected local:remote, got %q", spec)
+	}
+
+	local, remote, found := strings.Cut(spec, ":")
+	if !found {
+		remote = local
+	}
+	localPort, err := strconv.Atoi(local)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid local port %q", local)
+	}
+
+	return localPort, remote, nil
+}
+
+// serviceItem is a row of the Services view.
+type serviceItem struct {
+	name        string
+	serviceType corev1.ServiceType
+	ports       string
+}
+
+func (s serviceItem) Title() string {
+	return fmt.Sprintf("%-40s  %-12s  %s", s.name, s.serviceType, s.ports)
+}
+func (s serviceItem) FilterValue() string { return s.name }
+
+// serviceItemList lists the services of a namespace with their ports, showing
+// errors as an entry.
+func serviceItemList(clientset *kubernetes.Clientset, namespace string) []list.Item {
+	services, err := clientset.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return []list.Item{item(err.Error())}
+	}
+
+	itemList := []list.Item{}
+	for _, svc := range services.Items {
+		var ports []string
+		for _, port := range svc.Spec.Ports {
+			p := strconv.Itoa(int(port.Port))
+			if port.Name != "" {
+				p = port.Name + ":" + p
+			}
+			ports = append(ports, p)
+		}
+		itemList = append(itemList, serviceItem{name: svc.Name, serviceType: svc.Spec.Type, ports: strings.Join(ports, ", ")})
+	}
+
+	return itemList
+}
+
+// startPortForward resolves the ports of spec against target, a pod name or
+// svc/NAME, and starts forwarding in the background. It returns once the
+// local listener is ready.
+func startPortForward(conn *kubeConnection, namespace string, target string, spec string) (*portForward, error) {
+	clientset := conn.clientset
+	localPort, remote, err := parseForwardSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	pod := target
+	var remotePort int
+	if service, ok := strings.CutPrefix(target, "svc/"); ok {
+		pod, remotePort, err = ResolveServicePort(clientset, namespace, service, remote)
+	} else {
+		target = "pod/" + pod
+		remotePort, err = resolvePodPort(clientset, namespace, pod, intstr.Parse(remote))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("portforward")
+
+	dialer, err := newPortForwardDialer(conn.config, req.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	pf := &portForward{
+		target:     target,
+		namespace:  namespace,
+		pod:        pod,
+		remotePort: remotePort,
+		stopChan:   make(chan struct{}),
+		status:     "starting",
+	}
+
+	readyChan := make(chan struct{})
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+	fw, err := portforward.NewOnAddresses(countingDialer{Dialer: dialer, forward: pf}, []string{"localhost"}, ports, pf.stopChan, readyChan, io.Discard, pf)
+	if err != nil {
+		return nil, err
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		err := fw.ForwardPorts()
+		if err != nil {
+			pf.setStatus("failed: " + err.Error())
+		} else {
+			pf.setStatus("stopped")
+		}
+		errChan <- err
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errChan:
+		if err == nil {
+			err = fmt.Errorf("port-forward stopped before becoming ready")
+		}
+		return nil, err
+	}
+
+	forwarded, err := fw.GetPorts()
+	if err == nil && len(forwarded) > 0 {
+		pf.mu.Lock()
+		pf.localPort = int(forwarded[0].Local)
+		pf.mu.Unlock()
+	}
+	pf.setStatus("active")
+
+	return pf, nil
+}
+
+// ResolveServicePort maps a service port (number or name) to a running pod
+// backing the service and the container port its targetPort points at.
+func ResolveServicePort(clientset *kubernetes.Clientset, namespace string, serviceName string, port string) (string, int, error) {
+	svc, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", 0, fmt.Errorf("service %q has no selector", serviceName)
+	}
+
+	var servicePort *corev1.ServicePort
+	for n, p := range svc.Spec.Ports {
+		if p.Name == port || strconv.Itoa(int(p.Port)) == port {
+			servicePort = &svc.Spec.Ports[n]
+			break
+		}
+	}
+	if servicePort == nil {
+		return "", 0, fmt.Errorf("service %q has no port %q", serviceName, port)
+	}
+
+	selector := labels.SelectorFromSet(svc.Spec.Selector).String()
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", 0, err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		targetPort := servicePort.TargetPort
+		if targetPort.IntValue() == 0 && targetPort.StrVal == "" {
+			targetPort = intstr.FromInt32(servicePort.Port)
+		}
+
+		containerPort, err := podContainerPort(&pod, targetPort)
+		if err != nil {
+			continue
+		}
+
+		return pod.Name, containerPort, nil
+	}
+
+	return "", 0, fmt.Errorf("no running pod backs service %q", serviceName)
+}
+
+func resolvePodPort(clientset *kubernetes.Clientset, namespace string, podName string, port intstr.IntOrString) (int, error) {
+	if port.Type == intstr.Int {
+		return port.IntValue(), nil
+	}
+
+	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	return podContainerPort(pod, port)
+}
+
+// podContainerPort resolves a numeric or named port against the pod's containers.
+func podContainerPort(pod *corev1.Pod, port intstr.IntOrString) (int, error) {
+	if port.Type == intstr.Int {
+		return port.IntValue(), nil
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, p := range container.Ports {
+			if p.Name == port.StrVal {
+				return int(p.ContainerPort), nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("pod %q has no port named %q", pod.Name, port.StrVal)
+}
+
+// countingDialer wraps the streams of a port-forward connection so the bytes
+// flowing through them can be reported.
+type countingDialer struct {
+	httpstream.Dialer
+	forward *portForward
+}
+
+func (d countingDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	conn, protocol, err := d.Dialer.Dial(protocols...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return countingConnection{Connection: conn, forward: d.forward}, protocol, nil
+}
+
+type countingConnection struct {
+	httpstream.Connection
+	forward *portForward
+}
+
+func (c countingConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	stream, err := c.Connection.CreateStream(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return countingStream{Stream: stream, forward: c.forward}, nil
+}
+
+type countingStream struct {
+	httpstream.Stream
+	forward *portForward
+}
+
+func (s countingStream) Read(p []byte) (int, error) {
+	n, err := s.Stream.Read(p)
+	s.forward.bytesIn.Add(int64(n))
+	return n, err
+}
+
+func (s countingStream) Write(p []byte) (int, error) {
+	n, err := s.Stream.Write(p)
+	s.forward.bytesOut.Add(int64(n))
+	return n, err
+}
+
+// humanBytes formats a byte count using binary units.
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptModal asks for a single line of input before running an action on
+// the entry it was opened for.
+type promptModal struct {
+	active bool
+	action string
+	target string
+	title  string
+	input  textinput.Model
+}
+
+func newPromptModal(action string, target string, title string, placeholder string) promptModal {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.Focus()
+	ti.CharLimit = 256
+	ti.Width = 48
+
+	return promptModal{
+		active: true,
+		action: action,
+		target: target,
+		title:  title,
+		input:  ti,
+	}
+}
+
+func (p promptModal) View() string {
+	return modalStyle.Render(fmt.Sprintf("%s\n\n%s\n\n[enter] confirm  [esc] cancel", p.title, p.input.View()))
+}
+
+// updatePrompt handles input while a prompt is open.
+func (m model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.selection):
+		m.prompt.active = false
+		return m.runPrompt(m.prompt.action, m.prompt.target, m.prompt.input.Value())
+
+	case msg.Type == tea.KeyEsc:
+		m.prompt.active = false
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.prompt.input, cmd = m.prompt.input.Update(msg)
+	return m, cmd
+}
+
+// runPrompt starts the action a prompt was opened for with the entered value.
+func (m model) runPrompt(action string, target string, value string) (tea.Model, tea.Cmd) {
+	conn, namespace := m.conn, m.currentNamespace
+	clientset := conn.clientset
+
+	switch action {
+	case "portforward":
+		// Services are always listed from one namespace; pods may be namespace/pod
+		if !strings.HasPrefix(target, "svc/") {
+			namespace, target = splitTarget(namespace, target)
+		}
+		return m, func() tea.Msg {
+			pf, err := startPortForward(conn, namespace, target, value)
+			return portForwardStartedMsg{forward: pf, err: err}
+		}
+
+	case "search":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Invalid regex: " + err.Error()))
+		}
+
+		m.displayList.ResetFilter()
+		m.search.pattern = re
+		cmd := m.applySearch()
+		m.jumpToMatch(true)
+
+		return m, cmd
+
+	case "debug":
+		image, targeted := parseDebugSpec(value)
+		targetContainer := ""
+		if targeted {
+			targetContainer = target
+		}
+
+		cmd := m.displayList.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Starting %s debug container in %s...", image, m.currentPod)))
+		return m, tea.Batch(cmd, debugCmd(clientset, namespace, m.currentPod, image, targetContainer))
+
+	case "download":
+		podName, containerName := m.currentPod, m.currentContainer
+		if value == "" {
+			value = "."
+		}
+
+		progress := startTransfer("Downloading", target, func(onProgress func(int64)) (int64, error) {
+			return DownloadFromContainer(conn, namespace, podName, containerName, target, value, onProgress)
+		})
+		m.transferStatus = transferMsg{verb: "Downloading", path: target}.String()
+		return m, waitForTransfer(progress)
+
+	case "upload":
+		podName, containerName := m.currentPod, m.currentContainer
+
+		progress := startTransfer("Uploading", value, func(onProgress func(int64)) (int64, error) {
+			return UploadToContainer(conn, namespace, podName, containerName, value, target, onProgress)
+		})
+		m.transferStatus = transferMsg{verb: "Uploading", path: value}.String()
+		return m, waitForTransfer(progress)
+
+	case "execcontainer":
+		m.execTargets = strings.Split(target, ",")
+		m.execContainer = strings.TrimSpace(value)
+		m.execBytes = 0
+		m.execError = ""
+		m.execInput.Reset()
+		m.currentView = 4
+
+		return m, nil
+
+	case "fanout":
+		ref, containerName := parseFanOutSpec(value)
+		selector, err := ResolveLogSelector(clientset, namespace, ref)
+		if err == nil && selector == "" {
+			err = fmt.Errorf("no selector given")
+		}
+		var targets []string
+		if err == nil {
+			targets, err = PodsMatching(clientset, namespace, selector)
+		}
+		if err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Fan-out exec failed: " + err.Error()))
+		}
+
+		m.execTargets = targets
+		m.execContainer = containerName
+		m.execBytes = 0
+		m.execError = ""
+		m.execInput.Reset()
+		m.currentView = 4
+
+		return m, nil
+
+	case "snippet":
+		name := strings.TrimSpace(value)
+		if name == "" {
+			return m, nil
+		}
+
+		if err := saveSnippet(*snippetsPath, snippet{Name: name, Command: target}); err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Saving snippet failed: " + err.Error()))
+		}
+		return m, m.displayList.NewStatusMessage(statusMessageStyle("Saved snippet " + name))
+
+	case "jumptime":
+		n, err := findTimestamp(m.displayList.VisibleItems(), value)
+		if err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle(err.Error()))
+		}
+
+		m.displayList.Select(n)
+		return m, nil
+
+	case "fields":
+		filters, err := parseFieldFilters(value)
+		if err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Invalid field filter: " + err.Error()))
+		}
+
+		m.displayList.ResetFilter()
+		m.search.fields = filters
+		return m, m.applySearch()
+
+	case "save":
+		mode, path, err := parseSaveSpec(value)
+		if err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle(err.Error()))
+		}
+
+		write := m.saveWriter(mode)
+		progress := startTransfer("Saving", path, func(onProgress func(int64)) (int64, error) {
+			return saveToFile(path, write, onProgress)
+		})
+		m.transferStatus = transferMsg{verb: "Saving", path: path}.String()
+		return m, waitForTransfer(progress)
+
+	case "aggregate":
+		selector, err := ResolveLogSelector(clientset, namespace, value)
+		if err != nil {
+			return m, m.displayList.NewStatusMessage(statusMessageStyle("Aggregated logs failed: " + err.Error()))
+		}
+
+		m.aggregator = newLogAggregator(clientset, namespace, selector)
+		m.aggregator.start()
+		m.aggregateSelector = selector
+		m.aggregateDropped = 0
+		m.currentLog = ""
+		m.search = logSearch{}
+		m.logViewport = logViewport{wrap: m.logViewport.wrap}
+		m.currentView = 8 // switch to aggregated log view
+		m.displayList = updateDisplayList(m, []list.Item{})
+
+		return m, m.aggregator.wait()
+	}
+
+	return m, nil
+}
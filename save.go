@@ -0,0 +1,147 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// progressWriter counts the bytes passing through it and reports them at most
+// every 100ms.
+type progressWriter struct {
+	w          io.Writer
+	written    int64
+	reported   time.Time
+	onProgress func(int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if time.Since(p.reported) > 100*time.Millisecond {
+		p.reported = time.Now()
+		p.onProgress(p.written)
+	}
+
+	return n, err
+}
+
+// saveToFile writes the output of write to path, gzip compressing it when the
+// path ends in .gz. The returned count is of uncompressed bytes.
+func saveToFile(path string, write func(io.Writer) error, onProgress func(int64)) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	counter := &progressWriter{w: w, onProgress: onProgress}
+	if err := write(counter); err != nil {
+		return counter.written, err
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return counter.written, err
+		}
+	}
+
+	return counter.written, f.Close()
+}
+
+// writeItems writes one list item per line.
+func writeItems(items []list.Item) func(io.Writer) error {
+	return func(w io.Writer) error {
+		for _, listItem := range items {
+			if _, err := fmt.Fprintln(w, listItem.FilterValue()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// writeFullLog streams the complete container log straight from the API server.
+func writeFullLog(clientset *kubernetes.Clientset, namespace string, podName string, containerName string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		req := clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{Container: containerName})
+		stream, err := req.Stream(context.TODO())
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		_, err = io.Copy(w, stream)
+		return err
+	}
+}
+
+// parseSaveSpec splits "[buffer|filtered|full] path" and expands a leading ~.
+func parseSaveSpec(spec string) (string, string, error) {
+	mode, path := "buffer", strings.TrimSpace(spec)
+	if first, rest, found := strings.Cut(path, " "); found {
+		switch first {
+		case "buffer", "filtered", "full":
+			mode, path = first, strings.TrimSpace(rest)
+		}
+	}
+
+	if path == "" {
+		return "", "", fmt.Errorf("no file path given")
+	}
+	return mode, expandHome(path), nil
+}
+
+// saveWriter picks what a save from the current view writes: every loaded
+// line, only the lines currently listed, or the full stream from the API.
+func (m model) saveWriter(mode string) func(io.Writer) error {
+	switch mode {
+	case "filtered":
+		return writeItems(m.displayList.VisibleItems())
+	case "full":
+		if m.currentView == 3 {
+			return writeFullLog(m.conn.clientset, m.currentNamespace, m.currentPod, m.currentContainer)
+		}
+
+		spool, execError := m.execSpool, m.execError
+		return func(w io.Writer) error {
+			if spool == "" {
+				return fmt.Errorf("the full output of this command was not kept")
+			}
+
+			f, err := os.Open(spool)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if _, err := io.Copy(w, f); err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, execError)
+			return err
+		}
+	}
+
+	// The whole buffer, including lines hidden by grep mode or field filters
+	if m.currentView == 3 && m.search.lines != nil {
+		return writeItems(m.search.lines)
+	}
+	return writeItems(m.displayList.Items())
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logSearch is the state of a regex search and field filters in the Logs view.
+// While lines are hidden, either by grep mode or field filters, lines keeps the
+// full log so it can be restored.
+type logSearch struct {
+	pattern *regexp.Regexp
+	matches []int
+	current int
+	grep    bool
+	context int
+	fields  []fieldFilter
+	raw     bool
+	lines   []list.Item
+}
+
+func (s logSearch) status() string {
+	var status []string
+	if s.pattern != nil {
+		position := 0
+		if len(s.matches) > 0 {
+			position = s.current + 1
+		}
+		search := fmt.Sprintf("/%s/  match %d of %d", s.pattern.String(), position, len(s.matches))
+		if s.grep {
+			search += fmt.Sprintf("  (grep, %d lines of context)", s.context)
+		}
+		status = append(status, search)
+	}
+
+	for _, filter := range s.fields {
+		op := "="
+		if filter.negate {
+			op = "!="
+		}
+		status = append(status, "where "+filter.key+op+filter.value)
+	}
+
+	return strings.Join(status, "  ")
+}
+
+// findMatches returns the indices of the items matching re as they are shown,
+// in columns for structured lines unless raw is set.
+func findMatches(items []list.Item, re *regexp.Regexp, raw bool) []int {
+	var matches []int
+	for n, listItem := range items {
+		i, ok := listItem.(item)
+		if ok && re.MatchString(displayText(string(i), raw)) {
+			matches = append(matches, n)
+		}
+	}
+
+	return matches
+}
+
+// grepItems keeps the lines matching re plus context lines around them,
+// separating groups that are not adjacent with "--" like grep does.
+func grepItems(lines []list.Item, re *regexp.Regexp, context int, raw bool) []list.Item {
+	keep := make([]bool, len(lines))
+	for _, n := range findMatches(lines, re, raw) {
+		for c := max(0, n-context); c <= min(len(lines)-1, n+context); c++ {
+			keep[c] = true
+		}
+	}
+
+	var itemList []list.Item
+	last := -1
+	for n, line := range lines {
+		if !keep[n] {
+			continue
+		}
+		if last >= 0 && n != last+1 {
+			itemList = append(itemList, item("--"))
+		}
+		itemList = append(itemList, line)
+		last = n
+	}
+
+	return itemList
+}
+
+// applySearch refreshes the listed lines, matches and highlighting after the
+// pattern, grep or field filter settings changed.
+func (m *model) applySearch() tea.Cmd {
+	var cmd tea.Cmd
+	if (m.search.grep && m.search.pattern != nil) || len(m.search.fields) > 0 {
+		if m.search.lines == nil {
+			m.search.lines = m.displayList.Items()
+		}
+
+		lines := filterByFields(m.search.lines, m.search.fields)
+		if m.search.grep && m.search.pattern != nil {
+			lines = grepItems(lines, m.search.pattern, m.search.context, m.search.raw)
+		}
+		cmd = m.displayList.SetItems(lines)
+	} else if m.search.lines != nil {
+		cmd = m.displayList.SetItems(m.search.lines)
+		m.search.lines = nil
+	}
+
+	m.displayList.SetDelegate(m.logDelegate())
+	m.search.matches = nil
+	m.search.current = 0
+	if m.search.pattern != nil {
+		m.search.matches = findMatches(m.displayList.VisibleItems(), m.search.pattern, m.search.raw)
+	}
+
+	return cmd
+}
+
+// logDelegate formats log lines with the current search settings.
+func (m model) logDelegate() itemDelegate {
+	return itemDelegate{highlight: m.search.pattern, raw: m.search.raw}
+}
+
+// pagerDelegate formats the lines of the view the log viewport is showing.
+// Files and exec output in the pager are shown as they are.
+func (m model) pagerDelegate() itemDelegate {
+	if m.currentView == 10 {
+		return itemDelegate{raw: true}
+	}
+
+	return m.logDelegate()
+}
+
+// jumpToMatch moves the cursor to the next (or previous) match relative to the
+// current cursor position, wrapping around at either end. Matches are counted
+// among the visible lines, as the list filter may have changed since.
+func (m *model) jumpToMatch(forward bool) {
+	if m.search.pattern != nil {
+		m.search.matches = findMatches(m.displayList.VisibleItems(), m.search.pattern, m.search.raw)
+	}
+	matches := m.search.matches
+	if len(matches) == 0 {
+		return
+	}
+
+	cursor := m.displayList.Index()
+	next := -1
+	if forward {
+		for n, match := range matches {
+			if match > cursor {
+				next = n
+				break
+			}
+		}
+		if next < 0 {
+			next = 0
+		}
+	} else {
+		for n := len(matches) - 1; n >= 0; n-- {
+			if matches[n] < cursor {
+				next = n
+				break
+			}
+		}
+		if next < 0 {
+			next = len(matches) - 1
+		}
+	}
+
+	m.search.current = next
+	m.displayList.Select(matches[next])
+	if i, ok := m.displayList.SelectedItem().(item); ok {
+		m.currentLog = string(i)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/muesli/cancelreader"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+var detachKeysSpec = flag.String("detach-keys", "ctrl+p,ctrl+q", "key sequence that detaches from an attached container without stopping it")
+
+// sessionEndedMsg is sent when an interactive session hands the terminal back.
+type sessionEndedMsg struct {
+	container string
+	err       error
+}
+
+// interactiveSession hands the terminal over to a container's stream while
+// tea.Exec has the TUI suspended. It satisfies tea.ExecCommand. Input is only
+// sent when the container has stdin; the detach keys work either way.
+type interactiveSession struct {
+	config *rest.Config
+	url    *url.URL
+	banner string
+	input  bool
+	tty    bool
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (s *interactiveSession) SetStdin(r io.Reader)  { s.stdin = r }
+func (s *interactiveSession) SetStdout(w io.Writer) { s.stdout = w }
+func (s *interactiveSession) SetStderr(w io.Writer) { s.stderr = w }
+
+func (s *interactiveSession) Run() error {
+	keys, err := parseDetachKeys(*detachKeysSpec)
+	if err != nil {
+		return err
+	}
+
+	exec, err := newExecutor(s.config, s.url)
+	if err != nil {
+		return fmt.Errorf("error while creating Executor: %v", err)
+	}
+
+	fmt.Fprintln(s.stdout, s.banner)
+
+	// The detach keys have to be seen as they are typed, so the local terminal
+	// is always raw. Without a remote TTY nothing translates newlines for it.
+	stdout, stderr := s.stdout, s.stderr
+	sizeQueue := &fixedSizeQueue{}
+	input := s.stdin
+	f, ok := s.stdin.(*os.File)
+	raw := ok && term.IsTerminal(int(f.Fd()))
+	if raw {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return err
+		}
+		defer term.Restore(int(f.Fd()), state)
+
+		if width, height, err := term.GetSize(int(f.Fd())); err == nil {
+			sizeQueue.size = &remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+		}
+		if !s.tty {
+			stdout, stderr = crlfWriter{s.stdout}, crlfWriter{s.stderr}
+		}
+	}
+	if ok {
+		// Stop reading the terminal once the session is over, or the next
+		// key press would never reach the TUI
+		if cr, err := cancelreader.NewReader(f); err == nil {
+			defer cr.Cancel()
+			input = cr
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	released := make(chan struct{})
+	detach := &detachReader{r: input, keys: keys, detach: cancel, released: released}
+	if raw && !s.tty {
+		detach.echo = stdout
+	}
+
+	options := remotecommand.StreamOptions{Stdout: stdout, Tty: s.tty}
+	if s.tty {
+		options.TerminalSizeQueue = sizeQueue
+	} else {
+		options.Stderr = stderr
+	}
+	if s.input {
+		options.Stdin = detach
+	} else {
+		go io.Copy(io.Discard, detach)
+	}
+
+	err = exec.StreamWithContext(ctx, options)
+	close(released)
+	if detach.detached.Load() {
+		return nil
+	}
+
+	return err
+}
+
+// fixedSizeQueue reports the terminal size once when the session starts.
+type fixedSizeQueue struct {
+	size *remotecommand.TerminalSize
+}
+
+func (q *fixedSizeQueue) Next() *remotecommand.TerminalSize {
+	size := q.size
+	q.size = nil
+	return size
+}
+
+// detachReader passes input through until the detach keys are typed. Keys that
+// start the sequence are held back until it is clear they are not part of it.
+type detachReader struct {
+	r        io.Reader
+	keys     []byte
+	match    int
+	pending  []byte
+	buf      [1024]byte
+	echo     io.Writer
+	detach   func()
+	detached atomic.Bool
+	released chan struct{}
+}
+
+func (d *detachReader) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		n, err := d.r.Read(d.buf[:])
+		for _, b := range d.buf[:n] {
+			if b == d.keys[d.match] {
+				d.match++
+				if d.match == len(d.keys) {
+					return d.stop()
+				}
+				continue
+			}
+
+			d.pending = append(d.pending, d.keys[:d.match]...)
+			d.pending = append(d.pending, b)
+			d.match = 0
+		}
+
+		if d.echo != nil {
+			d.echo.Write(bytes.ReplaceAll(d.pending, []byte("\r"), []byte("\r\n")))
+			d.pending = bytes.ReplaceAll(d.pending, []byte("\r"), []byte("\n"))
+		}
+		if err != nil && len(d.pending) == 0 {
+			select {
+			case <-d.released:
+				// Reading was cancelled because the session ended
+				return 0, io.EOF
+			default:
+				return 0, err
+			}
+		}
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// stop ends the session. Input only reports EOF once the connection is gone,
+// so the container's stdin is never closed and the process keeps running.
+func (d *detachReader) stop() (int, error) {
+	d.detached.Store(true)
+	d.detach()
+	<-d.released
+
+	return 0, io.EOF
+}
+
+// crlfWriter turns newlines into the carriage return and newline a raw
+// terminal needs.
+type crlfWriter struct {
+	w io.Writer
+}
+
+func (c crlfWriter) Write(b []byte) (int, error) {
+	if _, err := c.w.Write(bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// parseDetachKeys turns "ctrl+p,ctrl+q" into the bytes the terminal sends.
+func parseDetachKeys(spec string) ([]byte, error) {
+	var keys []byte
+	for _, k := range strings.Split(spec, ",") {
+		k = strings.TrimSpace(k)
+		switch {
+		case len(k) == 1:
+			keys = append(keys, k[0])
+		case len(k) == 6 && strings.HasPrefix(k, "ctrl+") && k[5] >= 'a' && k[5] <= 'z':
+			keys = append(keys, k[5]-'a'+1)
+		case len(k) == 6 && strings.HasPrefix(k, "ctrl+") && k[5] >= '@' && k[5] <= '_':
+			keys = append(keys, k[5]-'@')
+		default:
+			return nil, fmt.Errorf("invalid detach key %q", k)
+		}
+	}
+
+	return keys, nil
+}
+
+// detachKeysHelp is shown in the banner of a session.
+func detachKeysHelp() string {
+	return strings.ReplaceAll(*detachKeysSpec, ",", " ")
+}
+
+// newAttachSession attaches to the main process of a container. Input and a
+// TTY are only requested when the container spec enables them.
+func newAttachSession(conn *kubeConnection, namespace string, podName string, containerName string, stdin bool, tty bool, banner string) *interactiveSession {
+	req := conn.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: containerName,
+			Stdin:     stdin,
+			Stdout:    true,
+			Stderr:    !tty,
+			TTY:       tty,
+		}, scheme.ParameterCodec)
+
+	return &interactiveSession{config: conn.config, url: req.URL(), banner: banner, input: stdin, tty: tty}
+}
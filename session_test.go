@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseDetachKeys(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []byte
+		wantErr bool
+	}{
+		{spec: "ctrl+p,ctrl+q", want: []byte{0x10, 0x11}},
+		{spec: "ctrl+p, ctrl+q", want: []byte{0x10, 0x11}},
+		{spec: "ctrl+@", want: []byte{0x00}},
+		{spec: "ctrl+]", want: []byte{0x1d}},
+		{spec: "ctrl+a,x", want: []byte{0x01, 'x'}},
+		{spec: "ctrl+P", want: []byte{0x10}},
+		{spec: "ctrl+1", wantErr: true},
+		{spec: "alt+x", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseDetachKeys(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDetachKeys(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("parseDetachKeys(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+var metricsInterval = flag.Duration("metrics-interval", 15*time.Second, "how often usage is sampled while a view showing it is open")
+
+// maxUsageSamples is how many readings are kept per pod and container.
+const maxUsageSamples = 240
+
+// sparklineWidth is the number of samples shown in table rows.
+const sparklineWidth = 12
+
+// chartHeight is the number of rows of each chart in the usage view.
+const chartHeight = 8
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// usageSample is one reading of a pod's or container's usage.
+type usageSample struct {
+	at     time.Time
+	cpu    int64
+	memory int64
+}
+
+// usageHistory keeps the readings taken while kuco is open, keyed by
+// namespace/pod and namespace/pod/container.
+type usageHistory struct {
+	samples map[string][]usageSample
+}
+
+func newUsageHistory() *usageHistory {
+	return &usageHistory{samples: map[string][]usageSample{}}
+}
+
+func (h *usageHistory) add(key string, sample usageSample) {
+	samples := append(h.samples[key], sample)
+	h.samples[key] = samples[max(len(samples)-maxUsageSamples, 0):]
+}
+
+// record remembers the usage of every pod of a namespace and its containers.
+// Pods and containers of the namespace that are gone are forgotten.
+func (h *usageHistory) record(namespace string, pods map[string]podUsage, at time.Time) {
+	seen := map[string]bool{}
+	for name, pod := range pods {
+		key := usageKey(namespace, name)
+		h.add(key, usageSample{at: at, cpu: pod.cpu, memory: pod.memory})
+		seen[key] = true
+		for container, usage := range pod.containers {
+			h.add(key+"/"+container, usageSample{at: at, cpu: usage.cpu, memory: usage.memory})
+			seen[key+"/"+container] = true
+		}
+	}
+
+	for key := range h.samples {
+		podNamespace, _, _ := strings.Cut(key, "/")
+		if (namespace == "" || podNamespace == namespace) && !seen[key] {
+			delete(h.samples, key)
+		}
+	}
+}
+
+// series returns the CPU and memory readings of a key, oldest first.
+func (h *usageHistory) series(key string) ([]int64, []int64) {
+	var cpu, memory []int64
+	for _, sample := range h.samples[key] {
+		cpu = append(cpu, sample.cpu)
+		memory = append(memory, sample.memory)
+	}
+
+	return cpu, memory
+}
+
+// sparklines renders the recent CPU and memory readings of a key.
+func (h *usageHistory) sparklines(key string) string {
+	cpu, memory := h.series(key)
+	return fmt.Sprintf("%-*s %-*s", sparklineWidth, sparkline(cpu, sparklineWidth), sparklineWidth, sparkline(memory, sparklineWidth))
+}
+
+// sparkline draws the last width values scaled to the largest of them.
+func sparkline(values []int64, width int) string {
+	values = values[max(len(values)-width, 0):]
+	top := int64(0)
+	for _, v := range values {
+		top = max(top, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if top > 0 {
+			level = int(v * int64(len(sparks)-1) / top)
+		}
+		b.WriteRune(sparks[level])
+	}
+
+	return b.String()
+}
+
+// chart draws values as columns of eighth blocks, chartHeight rows high and
+// at most width columns wide, labelling the top and bottom of the scale.
+func chart(values []int64, width int, format func(int64) string) []string {
+	values = values[max(len(values)-width, 0):]
+	top := int64(1)
+	for _, v := range values {
+		top = max(top, v)
+	}
+
+	label := max(len(format(top)), len(format(0)))
+	rows := make([]string, chartHeight)
+	for row := range rows {
+		// Eighths of a block filled below the top of this row
+		floor := int64(chartHeight-1-row) * 8
+
+		var b strings.Builder
+		for _, v := range values {
+			filled := v*chartHeight*8/top - floor
+			switch {
+			case filled >= 8:
+				b.WriteRune('█')
+			case filled > 0:
+				b.WriteRune(sparks[filled-1])
+			default:
+				b.WriteRune(' ')
+			}
+		}
+
+		axis := ""
+		switch row {
+		case 0:
+			axis = format(top)
+		case chartHeight - 1:
+			axis = format(0)
+		}
+		rows[row] = fmt.Sprintf("%*s ┤%s", label, axis, b.String())
+	}
+
+	return rows
+}
+
+func formatMillicores(v int64) string { return fmt.Sprintf("%dm", v) }
+
+// summary describes the latest, lowest and highest reading.
+func summary(values []int64, format func(int64) string) string {
+	if len(values) == 0 {
+		return "no samples yet"
+	}
+
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low, high = min(low, v), max(high, v)
+	}
+
+	return fmt.Sprintf("now %s  min %s  max %s", format(values[len(values)-1]), format(low), format(high))
+}
+
+// chartView renders the usage history of the pod picked in the Pods view.
+func (m model) chartView() string {
+	podKey := usageKey(m.currentNamespace, m.chartPod)
+	cpu, memory := m.usageHistory.series(podKey)
+	width := max(m.containerWidth-16, 10)
+
+	var b strings.Builder
+	title := fmt.Sprintf("[KUCO] Usage of %s (%d samples, every %s)", m.chartPod, len(cpu), *metricsInterval)
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+
+	if usage, ok := m.podUsage[m.chartPod]; ok {
+		b.WriteString(usage.String() + "  (% of request/limit)\n\n")
+	}
+	b.WriteString("CPU     " + summary(cpu, formatMillicores) + "\n")
+	b.WriteString(strings.Join(chart(cpu, width, formatMillicores), "\n") + "\n\n")
+	b.WriteString("Memory  " + summary(memory, humanBytes) + "\n")
+	b.WriteString(strings.Join(chart(memory, width, humanBytes), "\n") + "\n\n")
+
+	var containers []string
+	for k := range m.usageHistory.samples {
+		if container, ok := strings.CutPrefix(k, podKey+"/"); ok {
+			containers = append(containers, container)
+		}
+	}
+	sort.Strings(containers)
+	for _, container := range containers {
+		fmt.Fprintf(&b, "%-30s  cpu/mem %s\n", container, m.usageHistory.sparklines(podKey+"/"+container))
+	}
+
+	b.WriteString("\n" + m.displayList.Help.ShortHelpView([]key.Binding{m.keys.back}))
+
+	return b.String()
+}
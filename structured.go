@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	timeKeys  = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+	levelKeys = []string{"level", "lvl", "severity", "@level", "loglevel"}
+	msgKeys   = []string{"msg", "message", "@message", "log"}
+)
+
+// structuredLine is a JSON or logfmt log line broken into its fields.
+type structuredLine struct {
+	fields map[string]string
+	keys   []string
+}
+
+// parseLogLine parses a log line as parseStructured does, skipping the
+// timestamp the API server prefixes lines with when timestamps are shown.
+func parseLogLine(line string) (structuredLine, bool) {
+	if _, rest, ok := splitTimestamp(line); ok {
+		line = rest
+	}
+
+	return parseStructured(line)
+}
+
+// parseStructured detects JSON objects and logfmt lines. Anything else is
+// reported as unstructured.
+func parseStructured(line string) (structuredLine, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		return parseJSONLine(line)
+	}
+
+	return parseLogfmtLine(line)
+}
+
+func parseJSONLine(line string) (structuredLine, bool) {
+	// Numbers are kept as written, large integer IDs do not fit a float64
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return structuredLine{}, false
+	}
+
+	parsed := structuredLine{fields: map[string]string{}}
+	for k, v := range raw {
+		switch value := v.(type) {
+		case string:
+			parsed.fields[k] = value
+		case json.Number:
+			parsed.fields[k] = value.String()
+		default:
+			encoded, _ := json.Marshal(value)
+			parsed.fields[k] = string(encoded)
+		}
+		parsed.keys = append(parsed.keys, k)
+	}
+	sort.Strings(parsed.keys)
+
+	return parsed, true
+}
+
+// parseLogfmtLine requires every token to be a key=value pair and at least two
+// pairs, so plain text containing a stray "=" is not mistaken for logfmt.
+func parseLogfmtLine(line string) (structuredLine, bool) {
+	parsed := structuredLine{fields: map[string]string{}}
+
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " ")
+		if line == "" {
+			break
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || strings.ContainsAny(line[:eq], " \"") {
+			return structuredLine{}, false
+		}
+		k := line[:eq]
+		line = line[eq+1:]
+
+		var v string
+		if strings.HasPrefix(line, `"`) {
+			end := 1
+			for end < len(line) && (line[end] != '"' || line[end-1] == '\\') {
+				end++
+			}
+			if end >= len(line) {
+				return structuredLine{}, false
+			}
+			unquoted, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				unquoted = line[1:end]
+			}
+			v, line = unquoted, line[end+1:]
+		} else {
+			v, line, _ = strings.Cut(line, " ")
+		}
+
+		if _, ok := parsed.fields[k]; !ok {
+			parsed.keys = append(parsed.keys, k)
+		}
+		parsed.fields[k] = v
+	}
+
+	return parsed, len(parsed.keys) >= 2
+}
+
+// lookup returns the first of keys present in the line.
+func (s structuredLine) lookup(keys []string) (string, string) {
+	for _, k := range keys {
+		if v, ok := s.fields[k]; ok {
+			return k, v
+		}
+	}
+
+	return "", ""
+}
+
+// columns splits the line into time, level and message columns followed by
+// the remaining fields.
+func (s structuredLine) columns() (string, string, string, string) {
+	timeKey, ts := s.lookup(timeKeys)
+	levelKey, level := s.lookup(levelKeys)
+	msgKey, msg := s.lookup(msgKeys)
+
+	var rest []string
+	for _, k := range s.keys {
+		if k == timeKey || k == levelKey || k == msgKey {
+			continue
+		}
+		rest = append(rest, k+"="+s.fields[k])
+	}
+
+	return fmt.Sprintf("%-12s", formatLogTime(ts)), level, msg, strings.Join(rest, " ")
+}
+
+// text is the line as the columns show it, without colors.
+func (s structuredLine) text() string {
+	return s.pretty(func(text string) string { return text }, false)
+}
+
+// pretty renders the columns. highlight marks search matches in each plain
+// column before the level is colored, so escape codes are never matched.
+func (s structuredLine) pretty(highlight func(string) string, color bool) string {
+	ts, level, msg, rest := s.columns()
+
+	levelColumn := fmt.Sprintf("%-5s", strings.ToUpper(level))
+	if marked := highlight(levelColumn); marked != levelColumn {
+		levelColumn = marked
+	} else if color {
+		levelColumn = levelStyle(level).Render(levelColumn)
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s  %s", highlight(ts), levelColumn, highlight(msg), highlight(rest)))
+}
+
+// displayText is a log line as the Logs view shows it: structured lines in
+// columns unless raw lines were asked for, after any kubelet timestamp.
+func displayText(line string, raw bool) string {
+	if raw {
+		return line
+	}
+
+	prefix, text := "", line
+	if _, rest, ok := splitTimestamp(line); ok {
+		prefix, text = line[:len(line)-len(rest)], rest
+	}
+	if parsed, ok := parseStructured(text); ok {
+		return prefix + parsed.text()
+	}
+
+	return line
+}
+
+// expand lists every field of the line, one per row.
+func (s structuredLine) expand() string {
+	width := 0
+	for _, k := range s.keys {
+		width = max(width, len(k))
+	}
+
+	var b strings.Builder
+	for _, k := range s.keys {
+		fmt.Fprintf(&b, "%-*s  %s\n", width, k, s.fields[k])
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatLogTime shortens RFC3339 and epoch timestamps to a time of day.
+func formatLogTime(ts string) string {
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		return t.Format("15:04:05.000")
+	}
+
+	if epoch, err := strconv.ParseFloat(ts, 64); err == nil {
+		// Epochs in milliseconds are common too
+		if epoch > 1e12 {
+			epoch /= 1000
+		}
+		sec := int64(epoch)
+		return time.Unix(sec, int64((epoch-float64(sec))*1e9)).Format("15:04:05.000")
+	}
+
+	return ts
+}
+
+func levelStyle(level string) lipgloss.Style {
+	switch strings.ToLower(level) {
+	case "error", "err", "fatal", "panic", "critical", "crit":
+		return errorLevelStyle
+	case "warn", "warning":
+		return warnLevelStyle
+	case "info", "notice":
+		return infoLevelStyle
+	case "debug", "trace":
+		return debugLevelStyle
+	}
+
+	return lipgloss.NewStyle()
+}
+
+// fieldFilter is a single key=value (or key!=value) condition on structured lines.
+type fieldFilter struct {
+	key    string
+	value  string
+	negate bool
+}
+
+// parseFieldFilters parses space separated conditions such as "level=error app!=web".
+func parseFieldFilters(spec string) ([]fieldFilter, error) {
+	var filters []fieldFilter
+	for _, condition := range strings.Fields(spec) {
+		if k, v, found := strings.Cut(condition, "!="); found {
+			filters = append(filters, fieldFilter{key: k, value: v, negate: true})
+		} else if k, v, found := strings.Cut(condition, "="); found {
+			filters = append(filters, fieldFilter{key: k, value: v})
+		} else {
+			return nil, fmt.Errorf("expected key=value, got %q", condition)
+		}
+	}
+
+	return filters, nil
+}
+
+// filterByFields keeps the structured lines satisfying every filter. Values
+// are compared case-insensitively so level=error also matches ERROR.
+func filterByFields(lines []list.Item, filters []fieldFilter) []list.Item {
+	if len(filters) == 0 {
+		return lines
+	}
+
+	var itemList []list.Item
+	for _, line := range lines {
+		i, ok := line.(item)
+		if !ok {
+			continue
+		}
+		parsed, ok := parseLogLine(string(i))
+		if !ok {
+			continue
+		}
+
+		matched := true
+		for _, filter := range filters {
+			v, ok := parsed.fields[filter.key]
+			if ok && strings.EqualFold(v, filter.value) == filter.negate {
+				matched = false
+			} else if !ok && !filter.negate {
+				matched = false
+			}
+		}
+		if matched {
+			itemList = append(itemList, line)
+		}
+	}
+
+	return itemList
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseLogfmtLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantFields map[string]string
+		wantKeys   []string
+		wantOK     bool
+	}{
+		{
+			name:       "plain pairs",
+			line:       "level=info msg=started port=8080",
+			wantFields: map[string]string{"level": "info", "msg": "started", "port": "8080"},
+			wantKeys:   []string{"level", "msg", "port"},
+			wantOK:     true,
+		},
+		{
+			name:       "quoted value",
+			line:       `level=warn msg="disk \"data\" almost full"`,
+			wantFields: map[string]string{"level": "warn", "msg": `disk "data" almost full`},
+			wantKeys:   []string{"level", "msg"},
+			wantOK:     true,
+		},
+		{
+			name:       "repeated key keeps its position",
+			line:       "a=1 b=2 a=3",
+			wantFields: map[string]string{"a": "3", "b": "2"},
+			wantKeys:   []string{"a", "b"},
+			wantOK:     true,
+		},
+		{
+			name:       "empty value",
+			line:       "user= id=7",
+			wantFields: map[string]string{"user": "", "id": "7"},
+			wantKeys:   []string{"user", "id"},
+			wantOK:     true,
+		},
+		{name: "single pair", line: "level=info"},
+		{name: "plain text with equals", line: "set x=1 for now"},
+		{name: "unterminated quote", line: `level=info msg="oops`},
+		{name: "missing key", line: "=1 b=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseLogfmtLine(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("parseLogfmtLine() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !maps.Equal(got.fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", got.fields, tt.wantFields)
+			}
+			if !slices.Equal(got.keys, tt.wantKeys) {
+				t.Errorf("keys = %q, want %q", got.keys, tt.wantKeys)
+			}
+		})
+	}
+}
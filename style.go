@@ -16,4 +16,17 @@ var (
 
 	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
 	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	markedItemStyle   = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("214"))
+	alertItemStyle    = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("196"))
+	matchStyle        = lipgloss.NewStyle().Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0"))
+
+	errorLevelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+	warnLevelStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	infoLevelStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	debugLevelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	modalStyle = lipgloss.NewStyle().
+			Padding(1, 2).
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("196"))
 )
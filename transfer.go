@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// transferMsg reports how much of a save, download or upload is done. It
+// carries its own channel, so transfers running side by side keep their own
+// progress.
+type transferMsg struct {
+	verb     string
+	path     string
+	bytes    int64
+	done     bool
+	err      error
+	progress chan transferMsg
+}
+
+func (t transferMsg) String() string {
+	switch {
+	case t.err != nil:
+		return fmt.Sprintf("%s %s failed: %s", t.verb, t.path, t.err.Error())
+	case t.done:
+		return fmt.Sprintf("%s %s done, %s transferred", t.verb, t.path, humanBytes(t.bytes))
+	}
+
+	return fmt.Sprintf("%s %s... %s transferred", t.verb, t.path, humanBytes(t.bytes))
+}
+
+// startTransfer runs run in the background. Progress is delivered on the
+// returned channel, which is closed after the final message.
+func startTransfer(verb string, path string, run func(onProgress func(int64)) (int64, error)) chan transferMsg {
+	progress := make(chan transferMsg, 1)
+
+	go func() {
+		defer close(progress)
+
+		bytes, err := run(func(bytes int64) {
+			// Skip updates while the UI is still busy with the previous one
+			select {
+			case progress <- transferMsg{verb: verb, path: path, bytes: bytes, progress: progress}:
+			default:
+			}
+		})
+		progress <- transferMsg{verb: verb, path: path, bytes: bytes, done: true, err: err, progress: progress}
+	}()
+
+	return progress
+}
+
+func waitForTransfer(progress chan transferMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-progress
+		if !ok {
+			return nil
+		}
+
+		return msg
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+)
+
+var streamTransport = flag.String("transport", "auto", "streaming protocol for exec, attach and port-forward: auto (WebSocket, falling back to SPDY), websocket or spdy")
+
+// shouldFallback reports whether a WebSocket failure means the server or a
+// proxy in between does not speak it, rather than a failure of the command.
+func shouldFallback(err error) bool {
+	return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+}
+
+// newExecutor streams exec and attach sessions over the configured transport.
+func newExecutor(config *rest.Config, url *url.URL) (remotecommand.Executor, error) {
+	switch *streamTransport {
+	case "spdy":
+		return remotecommand.NewSPDYExecutor(config, "POST", url)
+	case "websocket":
+		return remotecommand.NewWebSocketExecutor(config, "GET", url.String())
+	case "auto":
+	default:
+		return nil, fmt.Errorf("unknown transport %q, use auto, websocket or spdy", *streamTransport)
+	}
+
+	websocketExec, err := remotecommand.NewWebSocketExecutor(config, "GET", url.String())
+	if err != nil {
+		return nil, err
+	}
+	spdyExec, err := remotecommand.NewSPDYExecutor(config, "POST", url)
+	if err != nil {
+		return nil, err
+	}
+
+	return remotecommand.NewFallbackExecutor(websocketExec, spdyExec, shouldFallback)
+}
+
+// newPortForwardDialer dials port-forward connections over the configured
+// transport. WebSocket port-forwarding tunnels SPDY, as the API server expects.
+func newPortForwardDialer(config *rest.Config, url *url.URL) (httpstream.Dialer, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+	spdyDialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+
+	switch *streamTransport {
+	case "spdy":
+		return spdyDialer, nil
+	case "websocket":
+		return portforward.NewSPDYOverWebsocketDialer(url, config)
+	case "auto":
+	default:
+		return nil, fmt.Errorf("unknown transport %q, use auto, websocket or spdy", *streamTransport)
+	}
+
+	websocketDialer, err := portforward.NewSPDYOverWebsocketDialer(url, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return portforward.NewFallbackDialer(websocketDialer, spdyDialer, shouldFallback), nil
+}
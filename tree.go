@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// health colors the status of a tree node.
+type health int
+
+const (
+	healthy health = iota
+	progressing
+	failing
+)
+
+func (h health) style() lipgloss.Style {
+	switch h {
+	case progressing:
+		return warnLevelStyle
+	case failing:
+		return errorLevelStyle
+	}
+
+	return infoLevelStyle
+}
+
+// kindOrder sorts the roots of the tree, workloads first.
+var kindOrder = map[string]int{
+	"Deployment":  0,
+	"StatefulSet": 1,
+	"DaemonSet":   2,
+	"CronJob":     3,
+	"ReplicaSet":  4,
+	"Job":         5,
+	"Pod":         6,
+	"Service":     7,
+}
+
+// treeNode is an object in the ownership tree of a namespace.
+type treeNode struct {
+	kind     string
+	name     string
+	status   string
+	health   health
+	pod      string
+	children []*treeNode
+}
+
+func (n *treeNode) label() string {
+	return n.kind + "/" + n.name
+}
+
+// treeRow is a visible row of the Tree view.
+type treeRow struct {
+	node     *treeNode
+	path     string
+	prefix   string
+	expanded bool
+}
+
+func (r treeRow) Title() string {
+	marker := "  "
+	if len(r.node.children) > 0 {
+		marker = "▸ "
+		if r.expanded {
+			marker = "▾ "
+		}
+	}
+
+	return r.prefix + marker + r.node.label() + "  " + r.node.health.style().Render(r.node.status)
+}
+func (r treeRow) FilterValue() string { return r.node.label() }
+
+// GetOwnerTree resolves the ownerReferences of the workloads, pods and
+// containers of a namespace into trees, plus one tree per Service with the
+// pods its selector picks. Kinds that cannot be listed are left out and
+// returned as failures.
+func GetOwnerTree(clientset *kubernetes.Clientset, namespace string) ([]*treeNode, []string) {
+	ctx, opts := context.TODO(), metav1.ListOptions{}
+
+	var failed []string
+	fail := func(kind string, err error) {
+		failed = append(failed, fmt.Sprintf("%s not shown: %v", kind, err))
+	}
+
+	nodes := map[types.UID]*treeNode{}
+	owners := map[types.UID]types.UID{}
+	add := func(meta metav1.ObjectMeta, node *treeNode) {
+		nodes[meta.UID] = node
+		if owner := metav1.GetControllerOfNoCopy(&meta); owner != nil {
+			owners[meta.UID] = owner.UID
+		}
+	}
+
+	if deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, opts); err != nil {
+		fail("Deployments", err)
+	} else {
+		for _, d := range deployments.Items {
+			add(d.ObjectMeta, deploymentNode(d))
+		}
+	}
+
+	if replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, opts); err != nil {
+		fail("ReplicaSets", err)
+	} else {
+		for _, rs := range replicaSets.Items {
+			add(rs.ObjectMeta, replicaSetNode(rs))
+		}
+	}
+
+	if statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, opts); err != nil {
+		fail("StatefulSets", err)
+	} else {
+		for _, sts := range statefulSets.Items {
+			add(sts.ObjectMeta, replicasNode("StatefulSet", sts.Name, sts.Status.ReadyReplicas, replicas(sts.Spec.Replicas)))
+		}
+	}
+
+	if daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, opts); err != nil {
+		fail("DaemonSets", err)
+	} else {
+		for _, ds := range daemonSets.Items {
+			add(ds.ObjectMeta, replicasNode("DaemonSet", ds.Name, ds.Status.NumberReady, ds.Status.DesiredNumberScheduled))
+		}
+	}
+
+	if cronJobs, err := clientset.BatchV1().CronJobs(namespace).List(ctx, opts); err != nil {
+		fail("CronJobs", err)
+	} else {
+		for _, cj := range cronJobs.Items {
+			add(cj.ObjectMeta, cronJobNode(cj))
+		}
+	}
+
+	if jobs, err := clientset.BatchV1().Jobs(namespace).List(ctx, opts); err != nil {
+		fail("Jobs", err)
+	} else {
+		for _, job := range jobs.Items {
+			add(job.ObjectMeta, jobNode(job))
+		}
+	}
+
+	var pods []corev1.Pod
+	if podList, err := clientset.CoreV1().Pods(namespace).List(ctx, opts); err != nil {
+		fail("Pods", err)
+	} else {
+		pods = podList.Items
+		for _, pod := range pods {
+			add(pod.ObjectMeta, podNode(pod))
+		}
+	}
+
+	var roots []*treeNode
+	for uid, node := range nodes {
+		if owner, ok := nodes[owners[uid]]; ok {
+			owner.children = append(owner.children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	if services, err := clientset.CoreV1().Services(namespace).List(ctx, opts); err != nil {
+		fail("Services", err)
+	} else {
+		for _, svc := range services.Items {
+			roots = append(roots, serviceNode(svc, pods))
+		}
+	}
+
+	sortTree(roots)
+
+	return roots, failed
+}
+
+func sortTree(nodes []*treeNode) {
+	sort.Slice(nodes, func(a, b int) bool {
+		if nodes[a].kind != nodes[b].kind {
+			return kindOrder[nodes[a].kind] < kindOrder[nodes[b].kind]
+		}
+		return nodes[a].name < nodes[b].name
+	})
+	for _, node := range nodes {
+		sortTree(node.children)
+	}
+}
+
+func replicas(count *int32) int32 {
+	if count == nil {
+		return 1
+	}
+
+	return *count
+}
+
+func replicasNode(kind string, name string, ready int32, desired int32) *treeNode {
+	node := &treeNode{kind: kind, name: name, status: fmt.Sprintf("%d/%d ready", ready, desired)}
+	if ready < desired {
+		node.health = progressing
+	}
+
+	return node
+}
+
+func deploymentNode(d appsv1.Deployment) *treeNode {
+	node := replicasNode("Deployment", d.Name, d.Status.ReadyReplicas, replicas(d.Spec.Replicas))
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse {
+			node.status += ", " + condition.Reason
+			node.health = failing
+		}
+	}
+
+	return node
+}
+
+func replicaSetNode(rs appsv1.ReplicaSet) *treeNode {
+	node := replicasNode("ReplicaSet", rs.Name, rs.Status.ReadyReplicas, replicas(rs.Spec.Replicas))
+	if rs.Annotations["deployment.kubernetes.io/revision"] != "" {
+		node.status += ", revision " + rs.Annotations["deployment.kubernetes.io/revision"]
+	}
+
+	return node
+}
+
+func cronJobNode(cj batchv1.CronJob) *treeNode {
+	node := &treeNode{kind: "CronJob", name: cj.Name, status: cj.Spec.Schedule}
+	if cj.Status.LastScheduleTime != nil {
+		node.status += fmt.Sprintf(", last run %s ago", humanAge(time.Since(cj.Status.LastScheduleTime.Time)))
+	}
+	if len(cj.Status.Active) > 0 {
+		node.status += fmt.Sprintf(", %d active", len(cj.Status.Active))
+	}
+	if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+		node.status += ", suspended"
+		node.health = progressing
+	}
+
+	return node
+}
+
+func jobNode(job batchv1.Job) *treeNode {
+	node := &treeNode{kind: "Job", name: job.Name, status: fmt.Sprintf("%d/%d succeeded", job.Status.Succeeded, replicas(job.Spec.Completions))}
+	if job.Status.Active > 0 {
+		node.status += fmt.Sprintf(", %d active", job.Status.Active)
+		node.health = progressing
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			node.status += ", failed: " + condition.Reason
+			node.health = failing
+		}
+	}
+
+	return node
+}
+
+func podNode(pod corev1.Pod) *treeNode {
+	node := &treeNode{kind: "Pod", name: pod.Name, pod: pod.Name}
+
+	ready, total := 0, len(pod.Spec.Containers)
+	for _, container := range pod.Spec.InitContainers {
+		node.children = append(node.children, containerNode(pod.Name, containerInfo("init", container.Name, container.Image, container.Stdin, container.TTY, pod.Status.InitContainerStatuses)))
+	}
+	for _, container := range pod.Spec.Containers {
+		info := containerInfo("main", container.Name, container.Image, container.Stdin, container.TTY, pod.Status.ContainerStatuses)
+		if info.Ready {
+			ready++
+		}
+		node.children = append(node.children, containerNode(pod.Name, info))
+	}
+
+	node.status = fmt.Sprintf("%s, %d/%d ready", pod.Status.Phase, ready, total)
+	switch {
+	case pod.Status.Phase == corev1.PodFailed:
+		node.health = failing
+	case pod.Status.Phase == corev1.PodSucceeded:
+		node.health = healthy
+	case ready < total:
+		node.health = progressing
+	}
+	for _, child := range node.children {
+		if child.health == failing && pod.Status.Phase != corev1.PodSucceeded {
+			node.health = failing
+		}
+	}
+
+	return node
+}
+
+func containerNode(podName string, info ContainerInfo) *treeNode {
+	node := &treeNode{kind: "Container", name: info.Name, pod: podName, status: info.State}
+	if info.Restarts > 0 {
+		node.status += fmt.Sprintf(", %d restarts", info.Restarts)
+	}
+
+	switch {
+	case strings.Contains(info.State, "BackOff") || strings.Contains(info.State, "Err"):
+		node.health = failing
+	case strings.HasPrefix(info.State, "Terminated") && !strings.HasSuffix(info.State, "(exit 0)"):
+		node.health = failing
+	case strings.HasPrefix(info.State, "Terminated"):
+		node.health = healthy
+	case !info.Ready && info.Type != "init":
+		node.health = progressing
+	}
+
+	return node
+}
+
+// serviceNode lists the pods a Service selects under it.
+func serviceNode(svc corev1.Service, pods []corev1.Pod) *treeNode {
+	node := &treeNode{kind: "Service", name: svc.Name}
+	if len(svc.Spec.Selector) > 0 {
+		selector := labels.SelectorFromSet(svc.Spec.Selector)
+		for _, pod := range pods {
+			if selector.Matches(labels.Set(pod.Labels)) {
+				node.children = append(node.children, podNode(pod))
+			}
+		}
+	}
+
+	node.status = fmt.Sprintf("%s, %d pod(s)", svc.Spec.Type, len(node.children))
+	switch {
+	case len(svc.Spec.Selector) == 0:
+		node.status = fmt.Sprintf("%s, no selector", svc.Spec.Type)
+	case len(node.children) == 0:
+		node.health = progressing
+	}
+
+	return node
+}
+
+// treeItemList flattens the expanded part of the tree into rows. Nodes are
+// expanded unless their path is collapsed.
+func treeItemList(roots []*treeNode, collapsed map[string]bool) []list.Item {
+	itemList := []list.Item{}
+
+	var walk func(nodes []*treeNode, parent string, indent string)
+	walk = func(nodes []*treeNode, parent string, indent string) {
+		for n, node := range nodes {
+			path := parent + "/" + node.label()
+			branch, childIndent := "├─ ", indent+"│  "
+			if n == len(nodes)-1 {
+				branch, childIndent = "└─ ", indent+"   "
+			}
+			if parent == "" {
+				branch, childIndent = "", ""
+			}
+
+			row := treeRow{node: node, path: path, prefix: indent + branch, expanded: !collapsed[path]}
+			itemList = append(itemList, row)
+			if row.expanded {
+				walk(node.children, path, childIndent)
+			}
+		}
+	}
+	walk(roots, "", "")
+
+	return itemList
+}
+
+// ownerTreeList fetches the tree of the current namespace for the Tree view,
+// showing the kinds that could not be listed as entries above it.
+func (m *model) ownerTreeList() []list.Item {
+	roots, failed := GetOwnerTree(m.conn.clientset, m.currentNamespace)
+	m.tree = roots
+
+	itemList := []list.Item{}
+	for _, failure := range failed {
+		itemList = append(itemList, item(failure))
+	}
+
+	return append(itemList, treeItemList(m.tree, m.treeCollapsed)...)
+}
+
+// selectedObject returns the kind, namespace and name of the object selected
+// in the Namespaces, Pods or Tree view. Containers are not objects of their own.
+func (m model) selectedObject() (string, string, string, bool) {
+	switch i := m.displayList.SelectedItem().(type) {
+	case item:
+		switch m.currentView {
+		case 0:
+			return "namespace", "", string(i), true
+		case 14:
+			// A kind the tree could not list
+			return "", "", "", false
+		}
+		namespace, name := splitTarget(m.currentNamespace, string(i))
+		return "pod", namespace, name, true
+	case treeRow:
+		if i.node.kind == "Container" {
+			return "", "", "", false
+		}
+		return strings.ToLower(i.node.kind), m.currentNamespace, i.node.name, true
+	}
+
+	return "", "", "", false
+}
+
+// toggleTreeRow expands or collapses the selected row, keeping it selected.
+func (m *model) toggleTreeRow() {
+	row, ok := m.displayList.SelectedItem().(treeRow)
+	if !ok || len(row.node.children) == 0 {
+		return
+	}
+
+	// Keep the kinds that could not be listed above the tree
+	var itemList []list.Item
+	for _, listItem := range m.displayList.Items() {
+		if _, ok := listItem.(treeRow); !ok {
+			itemList = append(itemList, listItem)
+		}
+	}
+
+	m.treeCollapsed[row.path] = row.expanded
+	index := m.displayList.Index()
+	m.displayList.SetItems(append(itemList, treeItemList(m.tree, m.treeCollapsed)...))
+	m.displayList.Select(index)
+}
@@ -5,12 +5,25 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
 	"k8s.io/client-go/kubernetes"
 )
 
 func updateDisplayList(m model, itemList []list.Item) list.Model {
 	listKeys := m.keys
-	currentList := list.New(itemList, itemDelegate{}, 0, 0)
+
+	clear(m.columns)
+	if m.currentView == 1 {
+		m.podColumns(itemList)
+	}
+	delegate := itemDelegate{columns: m.columns, raw: true}
+	if m.currentView == 0 || m.currentView == 1 {
+		// Marks outlive views opened from the list, but not entries that are gone
+		keepMarks(m.marked, itemList)
+		delegate.marked = m.marked
+	}
+	currentList := list.New(itemList, delegate, 0, 0)
+	setMarkedStatus(&currentList, len(delegate.marked))
 
 	if m.currentView != 4 {
 		currentList.AdditionalShortHelpKeys = func() []key.Binding {
@@ -28,10 +41,34 @@ func updateDisplayList(m model, itemList []list.Item) list.Model {
 		currentList.AdditionalShortHelpKeys = func() []key.Binding {
 			return []key.Binding{
 				listKeys.selection,
+				listKeys.mark,
+				listKeys.delete,
+				listKeys.allNamespaces,
+				listKeys.blockers,
+				listKeys.nodes,
 			}
 		}
 	case 1:
-		title = "[KUCO] Pods"
+		title = m.usageTitle(m.podsTitle())
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.selection,
+				listKeys.back,
+				listKeys.mark,
+				listKeys.delete,
+				listKeys.restart,
+				listKeys.bulkLogs,
+				listKeys.aggregateLogs,
+				listKeys.exec,
+				listKeys.fanOut,
+				listKeys.portForward,
+				listKeys.services,
+				listKeys.forwards,
+				listKeys.sortUsage,
+				listKeys.usageChart,
+				listKeys.ownerTree,
+			}
+		}
 	case 2:
 		title = "[KUCO] Containers"
 		currentList.AdditionalShortHelpKeys = func() []key.Binding {
@@ -39,11 +76,32 @@ func updateDisplayList(m model, itemList []list.Item) list.Model {
 				listKeys.selection,
 				listKeys.back,
 				listKeys.exec,
+				listKeys.attach,
+				listKeys.debug,
+				listKeys.files,
+				listKeys.snippets,
 			}
 		}
 	case 3:
 		title = "[KUCO] Logs"
 		currentList.Help.ShowAll = false
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.selection,
+				listKeys.back,
+				listKeys.search,
+				listKeys.nextMatch,
+				listKeys.prevMatch,
+				listKeys.grep,
+				listKeys.pretty,
+				listKeys.expand,
+				listKeys.fieldFilter,
+				listKeys.save,
+				listKeys.wrap,
+				listKeys.timestamps,
+				listKeys.jumpToTime,
+			}
+		}
 	case 4:
 		title = fmt.Sprintf("[KUCO] Command Output\n> %s", m.execInput.Value())
 
@@ -52,6 +110,101 @@ func updateDisplayList(m model, itemList []list.Item) list.Model {
 				listKeys.selection,
 				listKeys.back,
 				listKeys.exec,
+				listKeys.cancelExec,
+				listKeys.save,
+			}
+		}
+	case 6:
+		title = m.resultsTitle
+		if m.resultsAction == "Exec" {
+			if m.resultsGrouped {
+				title += " (grouped by output)"
+			}
+			currentList.AdditionalShortHelpKeys = func() []key.Binding {
+				return []key.Binding{
+					listKeys.selection,
+					listKeys.back,
+					listKeys.groupResults,
+				}
+			}
+		}
+	case 16:
+		title = "[KUCO] Aggregate logs of a workload in " + m.currentNamespace
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.selection,
+				listKeys.labelSelector,
+				listKeys.back,
+			}
+		}
+	case 15:
+		title = "[KUCO] Services of " + m.currentNamespace
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.selection,
+				listKeys.forwards,
+				listKeys.back,
+			}
+		}
+	case 14:
+		title = "[KUCO] Owner tree of " + m.currentNamespace
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.selection,
+				listKeys.toggleNode,
+				listKeys.delete,
+				listKeys.back,
+			}
+		}
+	case 12:
+		title = m.usageTitle("[KUCO] Nodes")
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.back,
+				listKeys.sortUsage,
+			}
+		}
+	case 11:
+		title = fmt.Sprintf("[KUCO] Snippets (run in %s)", m.currentContainer)
+		if len(m.execTargets) > 0 {
+			title = fmt.Sprintf("[KUCO] Snippets (run in %d pod(s))", len(m.execTargets))
+		}
+	case 10:
+		title = m.pagerTitle
+		currentList.Help.ShowAll = false
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.back,
+				listKeys.wrap,
+				listKeys.pageDown,
+				listKeys.pageUp,
+			}
+		}
+	case 9:
+		title = fmt.Sprintf("[KUCO] Files %s:%s", m.currentContainer, m.filesDir)
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.selection,
+				listKeys.back,
+				listKeys.download,
+				listKeys.upload,
+			}
+		}
+	case 8:
+		title = fmt.Sprintf("[KUCO] Aggregated Logs (%s)", m.aggregateSelector)
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.back,
+				listKeys.wrap,
+				listKeys.yankRange,
+			}
+		}
+	case 7:
+		title = "[KUCO] Port Forwards"
+		currentList.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{
+				listKeys.back,
+				listKeys.stopForward,
 			}
 		}
 	}
@@ -64,6 +217,11 @@ func updateDisplayList(m model, itemList []list.Item) list.Model {
 			listKeys.toggleStatusBar,
 			listKeys.togglePagination,
 			listKeys.toggleHelpMenu,
+			listKeys.moreContext,
+			listKeys.lessContext,
+			listKeys.yank,
+			listKeys.yankRange,
+			listKeys.yankManifest,
 		}
 	}
 
@@ -81,9 +239,22 @@ func listToItemList(kubeContext *kubernetes.Clientset, namespace string, viewMod
 	case 1:
 		stringList = GetPods(kubeContext, namespace)
 	case 2:
-		stringList = GetContainers(kubeContext, namespace, podName)
+		containers, err := GetContainers(kubeContext, namespace, podName)
+		if err != nil {
+			return []list.Item{item(err.Error())}
+		}
+
+		itemList := []list.Item{}
+		for _, container := range containers {
+			itemList = append(itemList, containerItem(container))
+		}
+		return itemList
 	case 3:
-		stringList = GetLogs(kubeContext, namespace, podName, containerName)
+		logLines, err := GetLogs(kubeContext, namespace, podName, containerName, false)
+		if err != nil {
+			return []list.Item{item(err.Error())}
+		}
+		stringList = logLines
 	}
 
 	itemList := []list.Item{}
@@ -93,3 +264,40 @@ func listToItemList(kubeContext *kubernetes.Clientset, namespace string, viewMod
 
 	return itemList
 }
+
+// setItemsKeepingSelection replaces the rows of l and moves the cursor back to
+// the row that was selected, so reordering never leaves it on another object.
+func setItemsKeepingSelection(l *list.Model, itemList []list.Item) tea.Cmd {
+	selected := l.SelectedItem()
+	cmd := l.SetItems(itemList)
+	if l.FilterState() == list.FilterApplied {
+		// Filter right away instead of in the background, so the selected row
+		// can be found among the matches
+		l.SetFilterText(l.FilterValue())
+		cmd = nil
+	}
+	if selected == nil || l.FilterState() == list.Filtering {
+		return cmd
+	}
+
+	for n, listItem := range l.VisibleItems() {
+		if listItem.FilterValue() == selected.FilterValue() {
+			l.Select(n)
+			break
+		}
+	}
+
+	return cmd
+}
+
+// selectedName returns the name of the object behind the selected list entry.
+func selectedName(l list.Model) string {
+	switch i := l.SelectedItem().(type) {
+	case item:
+		return string(i)
+	case containerItem:
+		return i.Name
+	}
+
+	return ""
+}